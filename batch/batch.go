@@ -0,0 +1,120 @@
+// Package batch 基于 DashScope Batch 接口的离线批量推理
+//
+// 流程：将请求写成JSONL输入文件并通过文件接口上传（Submit），创建批处理任务后轮询状态（Wait），
+// 任务结束后下载输出和错误文件，并按 custom_id 与输入请求对应（Fetch、Join）。
+// 对时效要求更高的任务可以用 Runner 在本地并发执行同样格式的请求，结果文件格式相同。
+package batch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// SubmitOptions 提交批处理任务的选项
+type SubmitOptions struct {
+	Filename         string            // 上传的文件名，默认 batch.jsonl
+	CompletionWindow string            // 完成时限，默认 24h
+	Metadata         map[string]string // 任务元数据
+}
+
+// Submit 上传请求并创建批处理任务
+func Submit(ctx context.Context, c *client.HTTPClient, reqs []Request, opts SubmitOptions) (*client.Batch, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("no requests to submit")
+	}
+	if opts.Filename == "" {
+		opts.Filename = "batch.jsonl"
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRequests(&buf, reqs); err != nil {
+		return nil, err
+	}
+
+	file, err := c.UploadFile(ctx, opts.Filename, &buf, client.FilePurposeBatch)
+	if err != nil {
+		return nil, fmt.Errorf("upload input file: %w", err)
+	}
+
+	return c.CreateBatch(ctx, client.CreateBatchRequest{
+		InputFileID:      file.ID,
+		CompletionWindow: opts.CompletionWindow,
+		Metadata:         opts.Metadata,
+	})
+}
+
+// Wait 每隔 interval 查询一次任务状态，直到任务结束或 ctx 取消
+// onPoll 不为nil时在每次查询后调用，可用于显示进度
+func Wait(ctx context.Context, c *client.HTTPClient, id string, interval time.Duration, onPoll func(*client.Batch)) (*client.Batch, error) {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		b, err := c.GetBatch(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if onPoll != nil {
+			onPoll(b)
+		}
+		if b.Done() {
+			return b, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return b, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Fetch 下载任务的输出文件和错误文件，返回两者合并后的结果
+func Fetch(ctx context.Context, c *client.HTTPClient, b *client.Batch) ([]Result, error) {
+	var results []Result
+	for _, id := range []string{b.OutputFileID, b.ErrorFileID} {
+		if id == "" {
+			continue
+		}
+
+		body, err := c.FileContent(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("download file %s: %w", id, err)
+		}
+		res, err := ReadResults(body)
+		body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("parse file %s: %w", id, err)
+		}
+		results = append(results, res...)
+	}
+	return results, nil
+}
+
+// Pair 输入请求与对应的结果
+type Pair struct {
+	Request Request
+	Result  *Result // 没有对应结果时为nil
+}
+
+// Join 按 custom_id 将结果与输入请求对应，顺序与输入一致
+func Join(reqs []Request, results []Result) []Pair {
+	byID := make(map[string]*Result, len(results))
+	for i := range results {
+		byID[results[i].CustomID] = &results[i]
+	}
+
+	pairs := make([]Pair, len(reqs))
+	for i, req := range reqs {
+		pairs[i] = Pair{Request: req, Result: byID[req.CustomID]}
+	}
+	return pairs
+}
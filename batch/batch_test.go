@@ -0,0 +1,308 @@
+package batch
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+	"github.com/lvdashuaibi/GPTUtils/config"
+)
+
+// fakeBatchService 模拟文件与批处理接口
+// custom_id 以 bad- 开头的请求写入错误文件，lost- 开头的请求不产生任何结果，其余写入输出文件
+type fakeBatchService struct {
+	t     *testing.T
+	polls int // 任务在第几次查询时完成
+
+	mu     sync.Mutex
+	files  map[string][]byte
+	gets   int
+	cancel bool
+}
+
+func newFakeBatchService(t *testing.T, polls int) *fakeBatchService {
+	return &fakeBatchService{t: t, polls: polls, files: map[string][]byte{}}
+}
+
+func (s *fakeBatchService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case r.Method == "POST" && r.URL.Path == "/files":
+		if r.FormValue("purpose") != client.FilePurposeBatch {
+			s.t.Errorf("purpose = %q", r.FormValue("purpose"))
+		}
+		f, _, err := r.FormFile("file")
+		if err != nil {
+			s.t.Errorf("form file: %v", err)
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var buf bytes.Buffer
+		buf.ReadFrom(f)
+		s.files["file-in"] = buf.Bytes()
+		s.writeJSON(w, client.File{ID: "file-in", Purpose: client.FilePurposeBatch})
+
+	case r.Method == "POST" && r.URL.Path == "/batches":
+		var req client.CreateBatchRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if _, ok := s.files[req.InputFileID]; !ok {
+			http.Error(w, `{"error":{"message":"input file not found"}}`, http.StatusBadRequest)
+			return
+		}
+		s.writeJSON(w, client.Batch{ID: "batch-1", Status: client.BatchValidating, InputFileID: req.InputFileID})
+
+	case r.Method == "GET" && r.URL.Path == "/batches/batch-1":
+		s.gets++
+		b := client.Batch{ID: "batch-1", Status: client.BatchInProgress}
+		switch {
+		case s.cancel:
+			b.Status = client.BatchCancelled
+		case s.gets >= s.polls:
+			b.Status = client.BatchCompleted
+			b.OutputFileID, b.ErrorFileID = s.process()
+		}
+		s.writeJSON(w, b)
+
+	case r.Method == "POST" && r.URL.Path == "/batches/batch-1/cancel":
+		s.cancel = true
+		s.writeJSON(w, client.Batch{ID: "batch-1", Status: client.BatchCancelling})
+
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/files/") && strings.HasSuffix(r.URL.Path, "/content"):
+		id := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/files/"), "/content")
+		data, ok := s.files[id]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// process 按输入文件生成输出文件和错误文件
+func (s *fakeBatchService) process() (outputID, errorID string) {
+	reqs, err := ReadRequests(bytes.NewReader(s.files["file-in"]))
+	if err != nil {
+		s.t.Errorf("read input file: %v", err)
+		return "", ""
+	}
+
+	var out, errs bytes.Buffer
+	for i, req := range reqs {
+		res := Result{ID: fmt.Sprintf("req-%d", i), CustomID: req.CustomID}
+		switch {
+		case strings.HasPrefix(req.CustomID, "lost-"):
+			continue
+		case strings.HasPrefix(req.CustomID, "bad-"):
+			res.Error = &ResultError{Code: "InvalidParameter", Message: "bad request"}
+			json.NewEncoder(&errs).Encode(res)
+		default:
+			res.Response = &Response{StatusCode: 200, RequestID: res.ID, Body: &client.ChatResponse{
+				Model: req.Body.Model,
+				Choices: []client.Choice{{Message: client.Message{
+					Role: "assistant", Content: "answer to " + req.Body.Messages[0].Content,
+				}}},
+			}}
+			json.NewEncoder(&out).Encode(res)
+		}
+	}
+
+	if out.Len() > 0 {
+		outputID = "file-out"
+		s.files[outputID] = out.Bytes()
+	}
+	if errs.Len() > 0 {
+		errorID = "file-err"
+		s.files[errorID] = errs.Bytes()
+	}
+	return outputID, errorID
+}
+
+func (s *fakeBatchService) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func newTestClient(t *testing.T, handler http.Handler) *client.HTTPClient {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return client.NewHTTPClient(&config.Config{APIKey: "test-key", BaseURL: srv.URL, Model: "qwen-plus"})
+}
+
+func chatRequest(customID, question string) Request {
+	return NewRequest(customID, client.ChatRequest{
+		Model:    "qwen-plus",
+		Messages: []client.Message{{Role: "user", Content: question}},
+	})
+}
+
+func TestSubmitWaitFetchJoin(t *testing.T) {
+	c := newTestClient(t, newFakeBatchService(t, 3))
+	ctx := context.Background()
+
+	reqs := []Request{
+		chatRequest("ok-1", "q1"),
+		chatRequest("bad-1", "q2"),
+		chatRequest("lost-1", "q3"),
+		chatRequest("ok-2", "q4"),
+	}
+
+	b, err := Submit(ctx, c, reqs, SubmitOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ID != "batch-1" || b.InputFileID != "file-in" {
+		t.Fatalf("batch = %+v", b)
+	}
+
+	polls := 0
+	b, err = Wait(ctx, c, b.ID, time.Millisecond, func(*client.Batch) { polls++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Status != client.BatchCompleted || polls != 3 {
+		t.Fatalf("status = %s after %d polls", b.Status, polls)
+	}
+	if b.OutputFileID == "" || b.ErrorFileID == "" {
+		t.Fatalf("batch files = %q, %q", b.OutputFileID, b.ErrorFileID)
+	}
+
+	results, err := Fetch(ctx, c, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+
+	pairs := Join(reqs, results)
+	if len(pairs) != len(reqs) {
+		t.Fatalf("got %d pairs, want %d", len(pairs), len(reqs))
+	}
+	for i, p := range pairs {
+		if p.Request.CustomID != reqs[i].CustomID {
+			t.Errorf("pair %d: custom_id = %q, want %q", i, p.Request.CustomID, reqs[i].CustomID)
+		}
+	}
+
+	// 输出文件中的结果
+	for _, i := range []int{0, 3} {
+		p := pairs[i]
+		if p.Result == nil || p.Result.Err() != nil {
+			t.Fatalf("%s: result = %+v", p.Request.CustomID, p.Result)
+		}
+		want := "answer to " + p.Request.Body.Messages[0].Content
+		if got := p.Result.Content(); got != want {
+			t.Errorf("%s: content = %q, want %q", p.Request.CustomID, got, want)
+		}
+	}
+
+	// 错误文件中的结果
+	if p := pairs[1]; p.Result == nil || p.Result.Err() == nil || p.Result.Content() != "" {
+		t.Errorf("bad-1: result = %+v", p.Result)
+	} else if p.Result.Error.Code != "InvalidParameter" {
+		t.Errorf("bad-1: error code = %q", p.Result.Error.Code)
+	}
+
+	// 没有对应结果的请求
+	if p := pairs[2]; p.Result != nil {
+		t.Errorf("lost-1: result = %+v, want nil", p.Result)
+	}
+}
+
+func TestWaitCancelled(t *testing.T) {
+	c := newTestClient(t, newFakeBatchService(t, 1000))
+	ctx := context.Background()
+
+	if _, err := Submit(ctx, c, []Request{chatRequest("ok-1", "q1")}, SubmitOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	polls := 0
+	b, err := Wait(ctx, c, "batch-1", time.Millisecond, func(*client.Batch) {
+		if polls++; polls == 2 {
+			if _, err := c.CancelBatch(ctx, "batch-1"); err != nil {
+				t.Errorf("cancel: %v", err)
+			}
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Status != client.BatchCancelled {
+		t.Errorf("status = %s, want %s", b.Status, client.BatchCancelled)
+	}
+
+	// 已取消的任务没有结果文件
+	results, err := Fetch(ctx, c, b)
+	if err != nil || len(results) != 0 {
+		t.Errorf("results = %v, err = %v", results, err)
+	}
+}
+
+func TestWaitContextCancel(t *testing.T) {
+	c := newTestClient(t, newFakeBatchService(t, 1000))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// 第一次查询后取消，Wait 返回最后一次查询到的状态
+	b, err := Wait(ctx, c, "batch-1", time.Hour, func(*client.Batch) { cancel() })
+	if err != context.Canceled {
+		t.Fatalf("err = %v, want %v", err, context.Canceled)
+	}
+	if b == nil || b.Status != client.BatchInProgress {
+		t.Errorf("batch = %+v", b)
+	}
+}
+
+func TestWriteRequestsCustomID(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteRequests(&buf, []Request{chatRequest("a", "q"), chatRequest("", "q")})
+	if err == nil || !strings.Contains(err.Error(), "custom_id is empty") {
+		t.Errorf("empty custom_id: err = %v", err)
+	}
+
+	buf.Reset()
+	err = WriteRequests(&buf, []Request{chatRequest("a", "q"), chatRequest("a", "q")})
+	if err == nil || !strings.Contains(err.Error(), "duplicate custom_id") {
+		t.Errorf("duplicate custom_id: err = %v", err)
+	}
+
+	// 空 custom_id 在上传前被拒绝
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	if _, err := Submit(context.Background(), c, []Request{chatRequest("", "q")}, SubmitOptions{}); err == nil {
+		t.Error("Submit accepted a request without custom_id")
+	}
+}
+
+func TestJoinResultWithoutCustomID(t *testing.T) {
+	reqs := []Request{chatRequest("a", "q1"), chatRequest("b", "q2")}
+	results, err := ReadResults(strings.NewReader(
+		`{"id":"r1","custom_id":"b","response":{"status_code":200,"body":{"choices":[{"message":{"role":"assistant","content":"B"}}]}}}` + "\n" +
+			`{"id":"r2","error":{"code":"InternalError","message":"lost custom_id"}}` + "\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pairs := Join(reqs, results)
+	if pairs[0].Result != nil {
+		t.Errorf("a: result = %+v, want nil", pairs[0].Result)
+	}
+	if pairs[1].Result == nil || pairs[1].Result.Content() != "B" {
+		t.Errorf("b: result = %+v", pairs[1].Result)
+	}
+}
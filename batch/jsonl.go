@@ -0,0 +1,165 @@
+package batch
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// maxLineSize JSONL 单行的最大长度
+const maxLineSize = 16 << 20
+
+// Request 批处理输入文件中的一行
+type Request struct {
+	CustomID string             `json:"custom_id"`
+	Method   string             `json:"method"`
+	URL      string             `json:"url"`
+	Body     client.ChatRequest `json:"body"`
+}
+
+// NewRequest 创建对话补全请求行
+func NewRequest(customID string, req client.ChatRequest) Request {
+	return Request{
+		CustomID: customID,
+		Method:   "POST",
+		URL:      client.BatchEndpointChat,
+		Body:     req,
+	}
+}
+
+// ResultError 单个请求的错误
+type ResultError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error 实现 error 接口
+func (e *ResultError) Error() string {
+	return e.Code + ": " + e.Message
+}
+
+// Response 单个请求的响应
+type Response struct {
+	StatusCode int                  `json:"status_code"`
+	RequestID  string               `json:"request_id"`
+	Body       *client.ChatResponse `json:"body"`
+}
+
+// Result 批处理输出或错误文件中的一行
+type Result struct {
+	ID       string       `json:"id"`
+	CustomID string       `json:"custom_id"`
+	Response *Response    `json:"response,omitempty"`
+	Error    *ResultError `json:"error,omitempty"`
+}
+
+// Content 返回回答内容，没有成功响应时为空
+func (r *Result) Content() string {
+	if r.Response == nil || r.Response.Body == nil || len(r.Response.Body.Choices) == 0 {
+		return ""
+	}
+	return r.Response.Body.Choices[0].Message.Content
+}
+
+// Err 返回请求的错误，成功时为nil
+func (r *Result) Err() error {
+	if r.Error != nil {
+		return r.Error
+	}
+	if r.Response == nil {
+		return &ResultError{Code: "no_response", Message: "result has neither response nor error"}
+	}
+	if r.Response.StatusCode != 200 {
+		return &ResultError{Code: fmt.Sprint(r.Response.StatusCode), Message: "request failed"}
+	}
+	return nil
+}
+
+// WriteRequests 将请求写为JSONL，custom_id 必须非空且唯一
+// 未设置 Method、URL 时使用对话补全接口的默认值
+func WriteRequests(w io.Writer, reqs []Request) error {
+	seen := make(map[string]bool, len(reqs))
+	enc := json.NewEncoder(w)
+	for i, req := range reqs {
+		if req.CustomID == "" {
+			return fmt.Errorf("request %d: custom_id is empty", i)
+		}
+		if seen[req.CustomID] {
+			return fmt.Errorf("request %d: duplicate custom_id %q", i, req.CustomID)
+		}
+		seen[req.CustomID] = true
+
+		if req.Method == "" {
+			req.Method = "POST"
+		}
+		if req.URL == "" {
+			req.URL = client.BatchEndpointChat
+		}
+		if err := enc.Encode(req); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadRequests 读取JSONL格式的请求，空行被忽略
+func ReadRequests(r io.Reader) ([]Request, error) {
+	var reqs []Request
+	err := readLines(r, func(lineNo int, line []byte) error {
+		var req Request
+		if err := json.Unmarshal(line, &req); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		reqs = append(reqs, req)
+		return nil
+	})
+	return reqs, err
+}
+
+// ReadRequestsFile 读取JSONL格式的请求文件
+func ReadRequestsFile(path string) ([]Request, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return ReadRequests(f)
+}
+
+// ReadResults 读取输出或错误文件，空行被忽略
+func ReadResults(r io.Reader) ([]Result, error) {
+	var results []Result
+	err := readLines(r, func(lineNo int, line []byte) error {
+		var res Result
+		if err := json.Unmarshal(line, &res); err != nil {
+			return fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		results = append(results, res)
+		return nil
+	})
+	return results, err
+}
+
+func readLines(r io.Reader, fn func(lineNo int, line []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Bytes()
+		if strings.TrimSpace(string(line)) == "" {
+			continue
+		}
+		if err := fn(lineNo, line); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,354 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// RunnerOptions 本地批量执行的选项
+type RunnerOptions struct {
+	Concurrency int           // 并发请求数，默认4
+	Model       string        // 请求未指定模型时使用的模型，默认使用客户端配置
+	Progress    io.Writer     // 进度条输出位置，nil表示不显示
+	Interval    time.Duration // 进度条刷新间隔，默认200毫秒
+}
+
+// Stats 本地批量执行的统计
+type Stats struct {
+	Total            int           // 输入请求数
+	Skipped          int           // 输出文件中已成功、本次跳过的请求数
+	Succeeded        int           // 本次成功的请求数
+	Failed           int           // 本次失败的请求数
+	PromptTokens     int           // 本次消耗的输入token数
+	CompletionTokens int           // 本次消耗的输出token数
+	Duration         time.Duration // 本次执行耗时
+}
+
+// String 返回统计摘要
+func (s Stats) String() string {
+	return fmt.Sprintf("共 %d，跳过 %d，成功 %d，失败 %d，token %d+%d，耗时 %s",
+		s.Total, s.Skipped, s.Succeeded, s.Failed,
+		s.PromptTokens, s.CompletionTokens, s.Duration.Round(time.Millisecond))
+}
+
+// Runner 不经过 Batch 接口、直接在本地并发执行批量请求
+//
+// 结果逐行追加到输出文件，格式与 Batch 接口的输出文件相同，可以用 ReadResults 读取。
+// 输出文件中已成功的 custom_id 会被跳过，因此中断后以相同参数重新运行即可续跑；
+// 失败的请求会重新执行，新结果追加在后面，Join 时以后出现的为准。
+// 限流与429重试由客户端的 RateLimiter 负责
+type Runner struct {
+	client *client.HTTPClient
+	opts   RunnerOptions
+}
+
+// NewRunner 创建本地批量执行器
+func NewRunner(c *client.HTTPClient, opts RunnerOptions) *Runner {
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 4
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = 200 * time.Millisecond
+	}
+	return &Runner{client: c, opts: opts}
+}
+
+// Run 执行请求并将结果追加到 output 文件
+// ctx 取消时停止发出新请求，已发出但被取消的请求不写入输出文件，下次运行时重新执行；
+// 写入输出文件失败时同样停止，统计只包含已写入的结果
+func (r *Runner) Run(ctx context.Context, reqs []Request, output string) (*Stats, error) {
+	seen := make(map[string]bool, len(reqs))
+	for i, req := range reqs {
+		if req.CustomID == "" {
+			return nil, fmt.Errorf("request %d: custom_id is required", i+1)
+		}
+		if seen[req.CustomID] {
+			return nil, fmt.Errorf("request %d: duplicate custom_id %q", i+1, req.CustomID)
+		}
+		seen[req.CustomID] = true
+	}
+
+	done, err := completedIDs(output)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := openOutput(output)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := &Stats{Total: len(reqs)}
+	var pending []Request
+	for _, req := range reqs {
+		if done[req.CustomID] {
+			stats.Skipped++
+			continue
+		}
+		pending = append(pending, req)
+	}
+
+	// 写入失败时通过 cancel 停止发出请求
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	start := time.Now()
+	jobs := make(chan Request)
+	results := make(chan Result)
+
+	var wg sync.WaitGroup
+	for i := 0; i < r.opts.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for req := range jobs {
+				res, ok := r.do(runCtx, req)
+				if ok {
+					results <- res
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, req := range pending {
+			select {
+			case jobs <- req:
+			case <-runCtx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	bar := newProgress(r.opts.Progress, stats, start)
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	var writeErr error
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	for results != nil {
+		select {
+		case res, ok := <-results:
+			if !ok {
+				results = nil
+				break
+			}
+			if writeErr != nil {
+				break
+			}
+			if writeErr = enc.Encode(res); writeErr != nil {
+				cancel()
+				break
+			}
+			if res.Error != nil {
+				stats.Failed++
+			} else {
+				stats.Succeeded++
+				if res.Response.Body != nil {
+					stats.PromptTokens += res.Response.Body.Usage.PromptTokens
+					stats.CompletionTokens += res.Response.Body.Usage.CompletionTokens
+				}
+			}
+		case <-ticker.C:
+			bar.draw()
+		}
+	}
+	stats.Duration = time.Since(start)
+	bar.finish()
+
+	if writeErr != nil {
+		return stats, fmt.Errorf("write output: %w", writeErr)
+	}
+	if err := f.Sync(); err != nil {
+		return stats, err
+	}
+	return stats, ctx.Err()
+}
+
+// do 执行单个请求，请求因 ctx 取消而失败时返回false
+func (r *Runner) do(ctx context.Context, req Request) (Result, bool) {
+	res := Result{ID: "local-" + req.CustomID, CustomID: req.CustomID}
+
+	if req.URL != "" && req.URL != client.BatchEndpointChat {
+		res.Error = &ResultError{Code: "unsupported_url", Message: "only " + client.BatchEndpointChat + " is supported"}
+		return res, true
+	}
+	if req.Body.Model == "" {
+		req.Body.Model = r.opts.Model
+	}
+
+	resp, err := r.client.Chat(ctx, req.Body)
+	if err != nil {
+		if ctx.Err() != nil {
+			return res, false
+		}
+		res.Error = resultError(err)
+		return res, true
+	}
+
+	res.Response = &Response{StatusCode: 200, RequestID: resp.ID, Body: resp}
+	return res, true
+}
+
+// resultError 将请求错误转换为结果中的错误
+func resultError(err error) *ResultError {
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		return &ResultError{Code: strconv.Itoa(apiErr.StatusCode), Message: apiErr.Body}
+	}
+	var vErr *client.ValidationError
+	if errors.As(err, &vErr) {
+		return &ResultError{Code: "invalid_request", Message: vErr.Error()}
+	}
+	return &ResultError{Code: "request_error", Message: err.Error()}
+}
+
+// completedIDs 读取已有输出文件中成功的 custom_id，文件不存在时返回空集合
+// 中断时可能留下不完整的最后一行，无法解析的行直接忽略
+func completedIDs(path string) (map[string]bool, error) {
+	done := make(map[string]bool)
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return done, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), maxLineSize)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var res Result
+		if err := json.Unmarshal(line, &res); err != nil {
+			continue
+		}
+		if res.Err() == nil {
+			done[res.CustomID] = true
+		}
+	}
+	return done, scanner.Err()
+}
+
+// openOutput 以追加方式打开输出文件
+// 上次中断留下的不完整行会被截掉，末尾缺少换行的完整行会补上换行
+func openOutput(path string) (*os.File, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := repairTail(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// repairTail 检查文件最后一个换行符之后的内容
+func repairTail(f *os.File) error {
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	end := info.Size()
+	start := int64(0)
+	buf := make([]byte, 4096)
+	for pos := end; pos > 0; {
+		n := int64(len(buf))
+		if pos < n {
+			n = pos
+		}
+		pos -= n
+		if _, err := f.ReadAt(buf[:n], pos); err != nil {
+			return err
+		}
+		if i := bytes.LastIndexByte(buf[:n], '\n'); i >= 0 {
+			start = pos + int64(i) + 1
+			break
+		}
+	}
+	if start == end {
+		return nil
+	}
+
+	tail := make([]byte, end-start)
+	if _, err := f.ReadAt(tail, start); err != nil {
+		return err
+	}
+	if json.Valid(tail) {
+		_, err = f.Write([]byte("\n"))
+		return err
+	}
+	return f.Truncate(start)
+}
+
+// progress 在一行内刷新的进度条
+type progress struct {
+	w     io.Writer
+	stats *Stats
+	start time.Time
+}
+
+func newProgress(w io.Writer, stats *Stats, start time.Time) *progress {
+	return &progress{w: w, stats: stats, start: start}
+}
+
+// draw 绘制进度条，例如 [=========>          ] 45/100 成功 40 失败 5 3.2/s 剩余 17s
+func (p *progress) draw() {
+	if p.w == nil {
+		return
+	}
+
+	const width = 30
+	s := p.stats
+	finished := s.Skipped + s.Succeeded + s.Failed
+	filled := width
+	if s.Total > 0 {
+		filled = finished * width / s.Total
+	}
+	bar := strings.Repeat("=", filled)
+	if filled < width {
+		bar += ">" + strings.Repeat(" ", width-filled-1)
+	}
+
+	elapsed := time.Since(p.start)
+	line := fmt.Sprintf("\r[%s] %d/%d 成功 %d 失败 %d", bar, finished, s.Total, s.Succeeded, s.Failed)
+	if ran := s.Succeeded + s.Failed; ran > 0 && elapsed > 0 {
+		rate := float64(ran) / elapsed.Seconds()
+		remaining := time.Duration(float64(s.Total-finished) / rate * float64(time.Second))
+		line += fmt.Sprintf(" %.1f/s 剩余 %s", rate, remaining.Round(time.Second))
+	}
+	fmt.Fprintf(p.w, "%s   ", line)
+}
+
+// finish 绘制最终状态并换行
+func (p *progress) finish() {
+	if p.w == nil {
+		return
+	}
+	p.draw()
+	fmt.Fprintln(p.w)
+}
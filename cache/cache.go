@@ -0,0 +1,216 @@
+// Package cache 提供聊天请求的响应缓存中间件
+//
+// 缓存按请求的规范化哈希（模型、消息、采样参数等）命中，
+// 默认只缓存确定性请求（temperature 为0或显式指定了 seed）。
+package cache
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// Options 缓存中间件选项
+type Options struct {
+	Store Store         // 缓存存储，为nil时使用容量1000的内存存储
+	TTL   time.Duration // 条目有效期，0表示永不过期
+
+	// CacheAll 为true时缓存所有请求，而不仅是确定性请求
+	CacheAll bool
+}
+
+// Stats 缓存命中统计
+type Stats struct {
+	Hits   int64
+	Misses int64
+	Errors int64 // 存储读写失败次数，失败时直接透传请求
+}
+
+// Middleware 响应缓存中间件，实现 client.Middleware
+type Middleware struct {
+	opts Options
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+// New 创建缓存中间件
+func New(opts Options) *Middleware {
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore(1000)
+	}
+	return &Middleware{opts: opts}
+}
+
+// Stats 返回命中统计
+func (m *Middleware) Stats() Stats {
+	return Stats{
+		Hits:   m.hits.Load(),
+		Misses: m.misses.Load(),
+		Errors: m.errors.Load(),
+	}
+}
+
+// Key 计算请求的规范化缓存键
+// 流式相关字段不参与计算，因此同一请求的流式与非流式调用共享缓存
+func Key(req client.ChatRequest) string {
+	req.Stream = false
+	req.StreamOptions = nil
+	return hashRequest(req)
+}
+
+// hashRequest 计算请求的哈希，包含不参与JSON序列化的 DashScope 原生参数
+// 未设置原生参数时只序列化请求本身，与之前的缓存键保持一致
+func hashRequest(req client.ChatRequest) string {
+	// 结构体字段按声明顺序序列化，map 按键排序，结果是确定的
+	var data []byte
+	if req.DashScope == nil {
+		data, _ = json.Marshal(req)
+	} else {
+		data, _ = json.Marshal(struct {
+			Request   client.ChatRequest
+			DashScope *client.DashScopeParams
+		}{req, req.DashScope})
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Deterministic 判断请求是否为确定性请求
+func Deterministic(req client.ChatRequest) bool {
+	return (req.Temperature != nil && *req.Temperature == 0) || req.Seed != nil
+}
+
+func (m *Middleware) cacheable(req client.ChatRequest) bool {
+	return m.opts.CacheAll || Deterministic(req)
+}
+
+func (m *Middleware) lookup(key string) (*Entry, bool) {
+	entry, ok, err := m.opts.Store.Get(key)
+	if err != nil {
+		m.errors.Add(1)
+		return nil, false
+	}
+	if ok {
+		m.hits.Add(1)
+	} else {
+		m.misses.Add(1)
+	}
+	return entry, ok
+}
+
+func (m *Middleware) store(key string, entry *Entry) {
+	entry.CreatedAt = time.Now()
+	if m.opts.TTL > 0 {
+		entry.ExpiresAt = entry.CreatedAt.Add(m.opts.TTL)
+	}
+	if err := m.opts.Store.Set(key, entry); err != nil {
+		m.errors.Add(1)
+	}
+}
+
+// WrapChat 实现 client.Middleware
+func (m *Middleware) WrapChat(next client.ChatFunc) client.ChatFunc {
+	return func(ctx context.Context, req client.ChatRequest) (*client.ChatResponse, error) {
+		if !m.cacheable(req) {
+			return next(ctx, req)
+		}
+
+		key := Key(req)
+		if entry, ok := m.lookup(key); ok {
+			resp := cloneResponse(&entry.Response)
+			resp.Meta.Cached = true
+			return resp, nil
+		}
+
+		resp, err := next(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		m.store(key, &Entry{Response: *cloneResponse(resp)})
+		return resp, nil
+	}
+}
+
+// cloneResponse 深拷贝响应，避免调用方修改缓存中的 Choices 等数据
+func cloneResponse(resp *client.ChatResponse) *client.ChatResponse {
+	c := *resp
+	c.Choices = append([]client.Choice(nil), resp.Choices...)
+	if d := resp.Usage.PromptTokensDetails; d != nil {
+		details := *d
+		c.Usage.PromptTokensDetails = &details
+	}
+	if info := resp.SearchInfo; info != nil {
+		c.SearchInfo = &client.SearchInfo{
+			SearchResults: append([]client.SearchResult(nil), info.SearchResults...),
+		}
+	}
+	return &c
+}
+
+// WrapChatStream 实现 client.Middleware
+// 命中时按记录的分块回放；未命中时记录分块，流正常结束后写入缓存
+func (m *Middleware) WrapChatStream(next client.ChatStreamFunc) client.ChatStreamFunc {
+	return func(ctx context.Context, req client.ChatRequest, handler client.StreamHandler) (*client.Usage, error) {
+		if !m.cacheable(req) {
+			return next(ctx, req, handler)
+		}
+
+		key := Key(req)
+		if entry, ok := m.lookup(key); ok {
+			return replay(ctx, entry, handler)
+		}
+
+		var chunks []string
+		usage, err := next(ctx, req, func(content string) error {
+			chunks = append(chunks, content)
+			return handler(content)
+		})
+		if err != nil {
+			return usage, err
+		}
+
+		resp := client.ChatResponse{
+			Object:  "chat.completion",
+			Created: time.Now().Unix(),
+			Model:   req.Model,
+			Choices: []client.Choice{{
+				Message:      client.Message{Role: "assistant", Content: strings.Join(chunks, "")},
+				FinishReason: "stop",
+			}},
+		}
+		if usage != nil {
+			resp.Usage = *usage
+		}
+		m.store(key, &Entry{Response: resp, Chunks: chunks})
+		return usage, nil
+	}
+}
+
+// replay 将缓存条目作为流回放给 handler
+// 回放不消耗token，因此返回的使用情况为nil
+func replay(ctx context.Context, entry *Entry, handler client.StreamHandler) (*client.Usage, error) {
+	chunks := entry.Chunks
+	if len(chunks) == 0 && len(entry.Response.Choices) > 0 {
+		chunks = []string{entry.Response.Choices[0].Message.Content}
+	}
+
+	for _, chunk := range chunks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if err := handler(chunk); err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,257 @@
+package cache
+
+import (
+	"context"
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// Embedder 计算文本向量，*client.HTTPClient 实现了该接口
+type Embedder interface {
+	Embed(ctx context.Context, text string) ([]float64, error)
+}
+
+// SemanticOptions 语义缓存选项
+type SemanticOptions struct {
+	Embedder  Embedder      // 向量计算，必填
+	Threshold float64       // 余弦相似度阈值，默认0.95
+	TTL       time.Duration // 条目有效期，0表示永不过期
+	Capacity  int           // 最多保留的条目数，默认1000，超出时淘汰最早的条目
+}
+
+// SemanticStats 语义缓存统计
+type SemanticStats struct {
+	Hits    int64
+	Misses  int64
+	Errors  int64 // 向量计算失败次数，失败时直接透传请求
+	Entries int
+}
+
+// SemanticMiddleware 语义缓存中间件，实现 client.Middleware
+//
+// 对最后一条用户消息计算向量，在相同上下文（模型与之前的消息）的历史问题中查找
+// 相似度超过阈值的问题，命中时直接返回缓存的回答。
+type SemanticMiddleware struct {
+	opts SemanticOptions
+
+	mu      sync.RWMutex
+	entries []*semanticEntry
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+type semanticEntry struct {
+	scope     string
+	question  string
+	vector    []float64
+	norm      float64
+	answer    client.ChatResponse
+	expiresAt time.Time
+}
+
+// NewSemantic 创建语义缓存中间件
+func NewSemantic(opts SemanticOptions) *SemanticMiddleware {
+	if opts.Threshold <= 0 {
+		opts.Threshold = 0.95
+	}
+	if opts.Capacity <= 0 {
+		opts.Capacity = 1000
+	}
+	return &SemanticMiddleware{opts: opts}
+}
+
+// Stats 返回命中统计
+func (m *SemanticMiddleware) Stats() SemanticStats {
+	m.mu.RLock()
+	n := len(m.entries)
+	m.mu.RUnlock()
+
+	return SemanticStats{
+		Hits:    m.hits.Load(),
+		Misses:  m.misses.Load(),
+		Errors:  m.errors.Load(),
+		Entries: n,
+	}
+}
+
+// Clear 清空缓存
+func (m *SemanticMiddleware) Clear() {
+	m.mu.Lock()
+	m.entries = nil
+	m.mu.Unlock()
+}
+
+// WrapChat 实现 client.Middleware
+func (m *SemanticMiddleware) WrapChat(next client.ChatFunc) client.ChatFunc {
+	return func(ctx context.Context, req client.ChatRequest) (*client.ChatResponse, error) {
+		scope, question, ok := m.split(req)
+		if !ok {
+			return next(ctx, req)
+		}
+
+		vector, err := m.opts.Embedder.Embed(ctx, question)
+		if err != nil {
+			m.errors.Add(1)
+			return next(ctx, req)
+		}
+
+		if entry := m.search(scope, vector); entry != nil {
+			m.hits.Add(1)
+			resp := cloneResponse(&entry.answer)
+			resp.Meta.Cached = true
+			return resp, nil
+		}
+		m.misses.Add(1)
+
+		resp, err := next(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+
+		m.add(scope, question, vector, *cloneResponse(resp))
+		return resp, nil
+	}
+}
+
+// WrapChatStream 实现 client.Middleware，命中时将缓存的回答作为单个分块输出
+func (m *SemanticMiddleware) WrapChatStream(next client.ChatStreamFunc) client.ChatStreamFunc {
+	return func(ctx context.Context, req client.ChatRequest, handler client.StreamHandler) (*client.Usage, error) {
+		scope, question, ok := m.split(req)
+		if !ok {
+			return next(ctx, req, handler)
+		}
+
+		vector, err := m.opts.Embedder.Embed(ctx, question)
+		if err != nil {
+			m.errors.Add(1)
+			return next(ctx, req, handler)
+		}
+
+		if entry := m.search(scope, vector); entry != nil {
+			m.hits.Add(1)
+			return replay(ctx, &Entry{Response: entry.answer}, handler)
+		}
+		m.misses.Add(1)
+
+		var sb strings.Builder
+		usage, err := next(ctx, req, func(content string) error {
+			sb.WriteString(content)
+			return handler(content)
+		})
+		if err != nil {
+			return usage, err
+		}
+
+		resp := client.ChatResponse{
+			Object:  "chat.completion",
+			Created: time.Now().Unix(),
+			Model:   req.Model,
+			Choices: []client.Choice{{
+				Message:      client.Message{Role: "assistant", Content: sb.String()},
+				FinishReason: "stop",
+			}},
+		}
+		if usage != nil {
+			resp.Usage = *usage
+		}
+		m.add(scope, question, vector, resp)
+		return usage, nil
+	}
+}
+
+// split 将请求拆分为上下文范围和最后一条用户消息
+// 最后一条消息不是用户消息时返回false
+func (m *SemanticMiddleware) split(req client.ChatRequest) (scope, question string, ok bool) {
+	if m.opts.Embedder == nil || len(req.Messages) == 0 {
+		return "", "", false
+	}
+
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role != "user" || strings.TrimSpace(last.Content) == "" {
+		return "", "", false
+	}
+
+	// 模型、采样参数和之前的消息共同决定上下文范围
+	req.Messages = req.Messages[:len(req.Messages)-1]
+	req.Stream = false
+	req.StreamOptions = nil
+
+	return hashRequest(req), last.Content, true
+}
+
+// search 查找同一范围内相似度最高且超过阈值的条目
+func (m *SemanticMiddleware) search(scope string, vector []float64) *semanticEntry {
+	norm := vectorNorm(vector)
+	if norm == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var best *semanticEntry
+	bestScore := m.opts.Threshold
+	for _, e := range m.entries {
+		if e.scope != scope || len(e.vector) != len(vector) {
+			continue
+		}
+		if !e.expiresAt.IsZero() && now.After(e.expiresAt) {
+			continue
+		}
+
+		var dot float64
+		for i := range vector {
+			dot += vector[i] * e.vector[i]
+		}
+		if score := dot / (norm * e.norm); score >= bestScore {
+			best, bestScore = e, score
+		}
+	}
+	return best
+}
+
+// add 写入条目，同时清理过期条目并按容量淘汰
+func (m *SemanticMiddleware) add(scope, question string, vector []float64, answer client.ChatResponse) {
+	entry := &semanticEntry{
+		scope:    scope,
+		question: question,
+		vector:   vector,
+		norm:     vectorNorm(vector),
+		answer:   answer,
+	}
+	if m.opts.TTL > 0 {
+		entry.expiresAt = time.Now().Add(m.opts.TTL)
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	live := m.entries[:0]
+	for _, e := range m.entries {
+		if e.expiresAt.IsZero() || now.Before(e.expiresAt) {
+			live = append(live, e)
+		}
+	}
+	live = append(live, entry)
+	if over := len(live) - m.opts.Capacity; over > 0 {
+		live = live[over:]
+	}
+	m.entries = live
+}
+
+func vectorNorm(v []float64) float64 {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	return math.Sqrt(sum)
+}
@@ -0,0 +1,192 @@
+package cache
+
+import (
+	"container/list"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// Entry 缓存条目
+type Entry struct {
+	Response  client.ChatResponse `json:"response"`
+	Chunks    []string            `json:"chunks,omitempty"` // 流式请求记录的原始分块，用于按原样回放
+	CreatedAt time.Time           `json:"created_at"`
+	ExpiresAt time.Time           `json:"expires_at,omitempty"` // 零值表示永不过期
+}
+
+// Expired 判断条目是否已过期
+func (e *Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
+
+// Store 缓存存储接口
+// 实现需要并发安全；Get 对过期条目应返回未命中
+type Store interface {
+	Get(key string) (*Entry, bool, error)
+	Set(key string, entry *Entry) error
+	Delete(key string) error
+}
+
+// MemoryStore 基于LRU淘汰的内存存储
+type MemoryStore struct {
+	capacity int
+
+	mu    sync.Mutex
+	ll    *list.List
+	items map[string]*list.Element
+}
+
+type memoryItem struct {
+	key   string
+	entry *Entry
+}
+
+// NewMemoryStore 创建内存存储，capacity 为最多保留的条目数，<=0 表示不限制
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		capacity: capacity,
+		ll:       list.New(),
+		items:    make(map[string]*list.Element),
+	}
+}
+
+// Get 获取条目
+func (s *MemoryStore) Get(key string) (*Entry, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.items[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	item := el.Value.(*memoryItem)
+	if item.entry.Expired(time.Now()) {
+		s.ll.Remove(el)
+		delete(s.items, key)
+		return nil, false, nil
+	}
+
+	s.ll.MoveToFront(el)
+	return item.entry, true, nil
+}
+
+// Set 写入条目，超出容量时淘汰最久未使用的条目
+func (s *MemoryStore) Set(key string, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		el.Value.(*memoryItem).entry = entry
+		s.ll.MoveToFront(el)
+		return nil
+	}
+
+	s.items[key] = s.ll.PushFront(&memoryItem{key: key, entry: entry})
+	for s.capacity > 0 && s.ll.Len() > s.capacity {
+		oldest := s.ll.Back()
+		s.ll.Remove(oldest)
+		delete(s.items, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+// Delete 删除条目
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		s.ll.Remove(el)
+		delete(s.items, key)
+	}
+	return nil
+}
+
+// Len 返回当前条目数
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ll.Len()
+}
+
+// DiskStore 每个条目一个JSON文件的磁盘存储
+type DiskStore struct {
+	dir string
+}
+
+// NewDiskStore 创建磁盘存储，目录不存在时自动创建
+func NewDiskStore(dir string) (*DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &DiskStore{dir: dir}, nil
+}
+
+func (s *DiskStore) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+// Get 获取条目，过期条目会被删除
+func (s *DiskStore) Get(key string) (*Entry, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		// 损坏的缓存文件视为未命中
+		_ = os.Remove(s.path(key))
+		return nil, false, nil
+	}
+
+	if entry.Expired(time.Now()) {
+		_ = os.Remove(s.path(key))
+		return nil, false, nil
+	}
+
+	return &entry, true, nil
+}
+
+// Set 写入条目，先写临时文件再重命名，避免并发读到半个文件
+func (s *DiskStore) Set(key string, entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(s.dir, key+".*.tmp")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path(key))
+}
+
+// Delete 删除条目
+func (s *DiskStore) Delete(key string) error {
+	err := os.Remove(s.path(key))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
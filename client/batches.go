@@ -0,0 +1,134 @@
+package client
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+)
+
+// 批处理任务状态
+const (
+	BatchValidating = "validating"
+	BatchInProgress = "in_progress"
+	BatchFinalizing = "finalizing"
+	BatchCompleted  = "completed"
+	BatchFailed     = "failed"
+	BatchExpired    = "expired"
+	BatchCancelling = "cancelling"
+	BatchCancelled  = "cancelled"
+)
+
+// BatchEndpointChat 批处理的对话补全接口
+const BatchEndpointChat = "/v1/chat/completions"
+
+// BatchRequestCounts 批处理任务的请求计数
+type BatchRequestCounts struct {
+	Total     int `json:"total"`
+	Completed int `json:"completed"`
+	Failed    int `json:"failed"`
+}
+
+// Batch 批处理任务
+type Batch struct {
+	ID               string `json:"id"`
+	Object           string `json:"object"`
+	Endpoint         string `json:"endpoint"`
+	InputFileID      string `json:"input_file_id"`
+	CompletionWindow string `json:"completion_window"`
+	Status           string `json:"status"`
+	OutputFileID     string `json:"output_file_id,omitempty"`
+	ErrorFileID      string `json:"error_file_id,omitempty"`
+
+	CreatedAt   int64 `json:"created_at"`
+	InProgress  int64 `json:"in_progress_at,omitempty"`
+	ExpiresAt   int64 `json:"expires_at,omitempty"`
+	CompletedAt int64 `json:"completed_at,omitempty"`
+	FailedAt    int64 `json:"failed_at,omitempty"`
+	CancelledAt int64 `json:"cancelled_at,omitempty"`
+
+	RequestCounts BatchRequestCounts `json:"request_counts"`
+	Metadata      map[string]string  `json:"metadata,omitempty"`
+
+	Errors *struct {
+		Data []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+			Line    int    `json:"line,omitempty"`
+		} `json:"data"`
+	} `json:"errors,omitempty"`
+}
+
+// Done 判断任务是否已经结束（完成、失败、过期或已取消）
+func (b *Batch) Done() bool {
+	switch b.Status {
+	case BatchCompleted, BatchFailed, BatchExpired, BatchCancelled:
+		return true
+	}
+	return false
+}
+
+// CreateBatchRequest 创建批处理任务的请求
+type CreateBatchRequest struct {
+	InputFileID      string            `json:"input_file_id"`
+	Endpoint         string            `json:"endpoint"`          // 默认 BatchEndpointChat
+	CompletionWindow string            `json:"completion_window"` // 默认 24h
+	Metadata         map[string]string `json:"metadata,omitempty"`
+}
+
+// CreateBatch 创建批处理任务
+func (c *HTTPClient) CreateBatch(ctx context.Context, req CreateBatchRequest) (*Batch, error) {
+	if req.Endpoint == "" {
+		req.Endpoint = BatchEndpointChat
+	}
+	if req.CompletionWindow == "" {
+		req.CompletionWindow = "24h"
+	}
+
+	var batch Batch
+	if err := c.doJSON(ctx, "POST", c.config.BaseURL+"/batches", req, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// GetBatch 查询批处理任务
+func (c *HTTPClient) GetBatch(ctx context.Context, id string) (*Batch, error) {
+	var batch Batch
+	if err := c.doJSON(ctx, "GET", c.config.BaseURL+"/batches/"+url.PathEscape(id), nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// CancelBatch 取消批处理任务
+func (c *HTTPClient) CancelBatch(ctx context.Context, id string) (*Batch, error) {
+	var batch Batch
+	if err := c.doJSON(ctx, "POST", c.config.BaseURL+"/batches/"+url.PathEscape(id)+"/cancel", nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// ListBatches 按创建时间倒序列出批处理任务，after 为上一页最后一个任务的ID，limit 为0时使用服务端默认值
+func (c *HTTPClient) ListBatches(ctx context.Context, after string, limit int) ([]Batch, error) {
+	query := url.Values{}
+	if after != "" {
+		query.Set("after", after)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	u := c.config.BaseURL + "/batches"
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var resp struct {
+		Data []Batch `json:"data"`
+	}
+	if err := c.doJSON(ctx, "GET", u, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
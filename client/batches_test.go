@@ -0,0 +1,167 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lvdashuaibi/GPTUtils/config"
+)
+
+// newTestClient 创建指向本地假服务的客户端
+func newTestClient(t *testing.T, handler http.Handler) *HTTPClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewHTTPClient(&config.Config{APIKey: "test-key", BaseURL: srv.URL, Model: "qwen-plus"})
+}
+
+// writeJSON 写出JSON响应
+func writeJSON(t *testing.T, w http.ResponseWriter, v interface{}) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		t.Errorf("encode response: %v", err)
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/files" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Errorf("parse multipart: %v", err)
+			return
+		}
+		if got := r.FormValue("purpose"); got != FilePurposeBatch {
+			t.Errorf("purpose = %q", got)
+		}
+		f, header, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("form file: %v", err)
+			return
+		}
+		defer f.Close()
+		data, _ := io.ReadAll(f)
+		if string(data) != "line1\nline2\n" {
+			t.Errorf("file content = %q", data)
+		}
+
+		writeJSON(t, w, File{ID: "file-1", Object: "file", Bytes: int64(len(data)),
+			Filename: header.Filename, Purpose: FilePurposeBatch})
+	}))
+
+	file, err := c.UploadFile(context.Background(), "in.jsonl", strings.NewReader("line1\nline2\n"), FilePurposeBatch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.ID != "file-1" || file.Filename != "in.jsonl" || file.Bytes != 12 {
+		t.Errorf("file = %+v", file)
+	}
+}
+
+func TestCreateBatchDefaults(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/batches" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		var req CreateBatchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Error(err)
+		}
+		if req.InputFileID != "file-1" || req.Endpoint != BatchEndpointChat || req.CompletionWindow != "24h" {
+			t.Errorf("request = %+v", req)
+		}
+		if req.Metadata["job"] != "nightly" {
+			t.Errorf("metadata = %v", req.Metadata)
+		}
+
+		writeJSON(t, w, Batch{ID: "batch-1", Status: BatchValidating, InputFileID: req.InputFileID})
+	}))
+
+	b, err := c.CreateBatch(context.Background(), CreateBatchRequest{
+		InputFileID: "file-1",
+		Metadata:    map[string]string{"job": "nightly"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.ID != "batch-1" || b.Done() {
+		t.Errorf("batch = %+v", b)
+	}
+}
+
+func TestGetAndCancelBatch(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /batches/batch-1", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, Batch{ID: "batch-1", Status: BatchInProgress,
+			RequestCounts: BatchRequestCounts{Total: 3, Completed: 1}})
+	})
+	mux.HandleFunc("POST /batches/batch-1/cancel", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(t, w, Batch{ID: "batch-1", Status: BatchCancelling})
+	})
+	c := newTestClient(t, mux)
+	ctx := context.Background()
+
+	b, err := c.GetBatch(ctx, "batch-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Status != BatchInProgress || b.RequestCounts.Total != 3 || b.RequestCounts.Completed != 1 {
+		t.Errorf("batch = %+v", b)
+	}
+
+	b, err = c.CancelBatch(ctx, "batch-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 取消中的任务尚未结束
+	if b.Status != BatchCancelling || b.Done() {
+		t.Errorf("batch = %+v", b)
+	}
+
+	_, err = c.GetBatch(ctx, "batch-missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("err = %v, want 404 APIError", err)
+	}
+}
+
+func TestFileContent(t *testing.T) {
+	c := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/files/file-out/content" {
+			http.NotFound(w, r)
+			return
+		}
+		io.WriteString(w, `{"custom_id":"a"}`+"\n")
+	}))
+	ctx := context.Background()
+
+	body, err := c.FileContent(ctx, "file-out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(body)
+	body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"custom_id":"a"}`+"\n" {
+		t.Errorf("content = %q", data)
+	}
+
+	_, err = c.FileContent(ctx, "file-missing")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		t.Errorf("err = %v, want 404 APIError", err)
+	}
+}
@@ -0,0 +1,177 @@
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/openai/openai-go"
+)
+
+// ChatClient 统一的聊天客户端接口，使用 ChatRequest、ChatResponse 与 StreamHandler
+// *HTTPClient、*NativeClient、*FallbackClient 直接实现该接口；
+// 基于 OpenAI SDK 的 *Client 通过 AsChatClient 适配；测试中可以用 ChatClientFuncs 注入假客户端
+type ChatClient interface {
+	Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+	ChatStream(ctx context.Context, req ChatRequest, handler StreamHandler) error
+}
+
+var (
+	_ ChatClient = (*HTTPClient)(nil)
+	_ ChatClient = (*NativeClient)(nil)
+	_ ChatClient = (*FallbackClient)(nil)
+	_ ChatClient = (*sdkChatClient)(nil)
+	_ ChatClient = ChatClientFuncs{}
+)
+
+// ChatClientFuncs 由函数实现的 ChatClient
+// OnStream 为nil时，流式调用通过 OnChat 获取完整回答后一次性交给 handler
+type ChatClientFuncs struct {
+	OnChat   ChatFunc
+	OnStream ChatStreamFunc
+}
+
+// Chat 实现 ChatClient 接口
+func (f ChatClientFuncs) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if f.OnChat == nil {
+		return nil, fmt.Errorf("chat is not implemented")
+	}
+	return f.OnChat(ctx, req)
+}
+
+// ChatStream 实现 ChatClient 接口
+func (f ChatClientFuncs) ChatStream(ctx context.Context, req ChatRequest, handler StreamHandler) error {
+	_, err := f.ChatStreamWithUsage(ctx, req, handler)
+	return err
+}
+
+// ChatStreamWithUsage 流式聊天，返回 OnStream 或 OnChat 报告的token使用情况
+func (f ChatClientFuncs) ChatStreamWithUsage(ctx context.Context, req ChatRequest, handler StreamHandler) (*Usage, error) {
+	if f.OnStream != nil {
+		return f.OnStream(ctx, req, handler)
+	}
+
+	resp, err := f.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) > 0 && resp.Choices[0].Message.Content != "" {
+		if err := handler(resp.Choices[0].Message.Content); err != nil {
+			return &resp.Usage, err
+		}
+	}
+	return &resp.Usage, nil
+}
+
+// AsChatClient 将基于 OpenAI SDK 的客户端适配为 ChatClient
+func (c *Client) AsChatClient() ChatClient {
+	return &sdkChatClient{client: c}
+}
+
+// sdkChatClient 将 ChatRequest 转换为 ChatOptions 调用 *Client
+type sdkChatClient struct {
+	client *Client
+}
+
+// Chat 实现 ChatClient 接口
+func (a *sdkChatClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	opts, err := chatOptions(req)
+	if err != nil {
+		return nil, err
+	}
+
+	completion, err := a.client.Chat(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return chatResponse(completion), nil
+}
+
+// ChatStream 实现 ChatClient 接口
+func (a *sdkChatClient) ChatStream(ctx context.Context, req ChatRequest, handler StreamHandler) error {
+	_, err := a.ChatStreamWithUsage(ctx, req, handler)
+	return err
+}
+
+// ChatStreamWithUsage 流式聊天，返回服务端报告的token使用情况（可能为nil）
+func (a *sdkChatClient) ChatStreamWithUsage(ctx context.Context, req ChatRequest, handler StreamHandler) (*Usage, error) {
+	opts, err := chatOptions(req)
+	if err != nil {
+		return nil, err
+	}
+	return a.client.ChatStreamWithUsage(ctx, opts, handler)
+}
+
+// chatOptions 将 ChatRequest 转换为 ChatOptions，ChatOptions 无法表示 req.DashScope，设置时返回错误
+func chatOptions(req ChatRequest) (ChatOptions, error) {
+	if err := checkNoDashScope(req); err != nil {
+		return ChatOptions{}, err
+	}
+
+	opts := ChatOptions{
+		Model:           req.Model,
+		Temperature:     req.Temperature,
+		TopP:            req.TopP,
+		PresencePenalty: req.PresencePenalty,
+		Seed:            req.Seed,
+		Stop:            req.Stop,
+		EnableSearch:    req.EnableSearch,
+		EnableThinking:  req.EnableThinking,
+	}
+	if req.MaxTokens != nil {
+		n := int64(*req.MaxTokens)
+		opts.MaxTokens = &n
+	}
+	if req.ResponseFormat != nil {
+		opts.ResponseFormat = &openai.ChatCompletionNewParamsResponseFormat{
+			Type: openai.F(openai.ChatCompletionNewParamsResponseFormatType(req.ResponseFormat.Type)),
+		}
+		if req.ResponseFormat.JSONSchema != nil {
+			opts.ResponseFormat.JSONSchema = openai.F(req.ResponseFormat.JSONSchema)
+		}
+	}
+
+	opts.Messages = make([]openai.ChatCompletionMessageParamUnion, len(req.Messages))
+	for i, msg := range req.Messages {
+		switch msg.Role {
+		case "system":
+			opts.Messages[i] = openai.SystemMessage(msg.Content)
+		case "user":
+			opts.Messages[i] = openai.UserMessage(msg.Content)
+		case "assistant":
+			opts.Messages[i] = openai.AssistantMessage(msg.Content)
+		default:
+			return ChatOptions{}, fmt.Errorf("message %d: unsupported role %q", i, msg.Role)
+		}
+	}
+	return opts, nil
+}
+
+// chatResponse 将 OpenAI SDK 的响应转换为 ChatResponse
+func chatResponse(completion *openai.ChatCompletion) *ChatResponse {
+	resp := &ChatResponse{
+		ID:      completion.ID,
+		Object:  string(completion.Object),
+		Created: completion.Created,
+		Model:   completion.Model,
+		Choices: make([]Choice, len(completion.Choices)),
+		Usage: Usage{
+			PromptTokens:     int(completion.Usage.PromptTokens),
+			CompletionTokens: int(completion.Usage.CompletionTokens),
+			TotalTokens:      int(completion.Usage.TotalTokens),
+		},
+	}
+	if cached := completion.Usage.PromptTokensDetails.CachedTokens; cached > 0 {
+		resp.Usage.PromptTokensDetails = &PromptTokensDetails{CachedTokens: int(cached)}
+	}
+	for i, choice := range completion.Choices {
+		resp.Choices[i] = Choice{
+			Index: int(choice.Index),
+			Message: Message{
+				Role:    string(choice.Message.Role),
+				Content: choice.Message.Content,
+			},
+			FinishReason: string(choice.FinishReason),
+		}
+	}
+	return resp
+}
@@ -2,7 +2,10 @@ package client
 
 import (
 	"context"
+	"encoding/json"
 	"github.com/lvdashuaibi/GPTUtils/config"
+	"github.com/lvdashuaibi/GPTUtils/models"
+	"net/http"
 
 	"github.com/openai/openai-go"
 	"github.com/openai/openai-go/option"
@@ -10,8 +13,10 @@ import (
 
 // Client 通义千问客户端
 type Client struct {
-	client *openai.Client
-	config *config.Config
+	client   *openai.Client
+	config   *config.Config
+	limiter  *RateLimiter
+	registry *models.Registry
 }
 
 // NewClient 创建新的客户端
@@ -20,15 +25,43 @@ func NewClient(cfg *config.Config) *Client {
 		cfg = config.DefaultConfig()
 	}
 
-	client := openai.NewClient(
+	client := openai.NewClient(clientOptions(cfg)...)
+
+	return &Client{
+		client:   client,
+		config:   cfg,
+		registry: cfg.ModelRegistry(),
+	}
+}
+
+// clientOptions 按配置生成 openai-go 客户端选项，服务商自定义的鉴权头与附加请求头覆盖默认的 Bearer 鉴权
+func clientOptions(cfg *config.Config) []option.RequestOption {
+	opts := []option.RequestOption{
 		option.WithAPIKey(cfg.APIKey),
 		option.WithBaseURL(cfg.BaseURL),
-	)
+	}
 
-	return &Client{
-		client: client,
-		config: cfg,
+	header := http.Header{}
+	cfg.SetHeaders(header)
+	if header.Get("Authorization") == "" {
+		opts = append(opts, option.WithHeaderDel("Authorization"))
+	}
+	for k := range header {
+		opts = append(opts, option.WithHeader(k, header.Get(k)))
 	}
+	return opts
+}
+
+// WithRateLimiter 设置客户端限流器，nil表示不限流
+func (c *Client) WithRateLimiter(limiter *RateLimiter) *Client {
+	c.limiter = limiter
+	return c
+}
+
+// WithModelRegistry 设置校验请求使用的模型注册表，默认为 cfg.ModelRegistry()
+func (c *Client) WithModelRegistry(registry *models.Registry) *Client {
+	c.registry = registry
+	return c
 }
 
 // ChatOptions 聊天选项
@@ -45,16 +78,53 @@ type ChatOptions struct {
 	ToolChoice        openai.ChatCompletionToolChoiceOptionUnionParam // 工具选择策略
 	ParallelToolCalls *bool                                           // 是否并行工具调用
 	EnableSearch      *bool                                           // 是否启用联网搜索
+	EnableThinking    *bool                                           // 是否启用深度思考
 	Seed              *int64                                          // 随机种子
 	Stop              []string                                        // 停止词
 	N                 *int64                                          // 生成响应数量
 }
 
 // Chat 发送聊天请求
+// 请求发送前按模型注册表校验参数，不支持的能力或超出范围的参数返回 *ValidationError
 func (c *Client) Chat(ctx context.Context, opts ChatOptions) (*openai.ChatCompletion, error) {
 	if opts.Model == "" {
 		opts.Model = c.config.Model
 	}
+	opts.Model = c.config.ResolveModel(opts.Model)
+	if err := c.validate(opts); err != nil {
+		return nil, err
+	}
+
+	params, reqOpts := newParams(opts, c.config.ProviderQuirks())
+
+	if c.limiter == nil {
+		return c.client.Chat.Completions.New(ctx, params, reqOpts...)
+	}
+
+	var completion *openai.ChatCompletion
+	err := c.limiter.run(ctx, opts.Model, optionsText(opts), func() (*Usage, error) {
+		resp, err := c.client.Chat.Completions.New(ctx, params, reqOpts...)
+		if err != nil {
+			return nil, err
+		}
+		completion = resp
+		return &Usage{
+			PromptTokens:     int(resp.Usage.PromptTokens),
+			CompletionTokens: int(resp.Usage.CompletionTokens),
+			TotalTokens:      int(resp.Usage.TotalTokens),
+		}, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return completion, nil
+}
+
+// newParams 将聊天选项转换为请求参数，兼容接口之外的扩展参数以请求选项的形式附加
+// quirks 中服务商不支持的参数会被删除，超出上限的 temperature 会被截断
+func newParams(opts ChatOptions, quirks config.Quirks) (openai.ChatCompletionNewParams, []option.RequestOption) {
+	opts.Temperature = clampTemperature(opts.Temperature, quirks)
 
 	params := openai.ChatCompletionNewParams{
 		Messages: openai.F(opts.Messages),
@@ -74,6 +144,9 @@ func (c *Client) Chat(ctx context.Context, opts ChatOptions) (*openai.ChatComple
 	if opts.PresencePenalty != nil {
 		params.PresencePenalty = openai.F(*opts.PresencePenalty)
 	}
+	if opts.ResponseFormat != nil {
+		params.ResponseFormat = openai.F[openai.ChatCompletionNewParamsResponseFormatUnion](*opts.ResponseFormat)
+	}
 	if len(opts.Tools) > 0 {
 		params.Tools = openai.F(opts.Tools)
 	}
@@ -95,7 +168,27 @@ func (c *Client) Chat(ctx context.Context, opts ChatOptions) (*openai.ChatComple
 		params.N = openai.F(*opts.N)
 	}
 
-	return c.client.Chat.Completions.New(ctx, params)
+	var reqOpts []option.RequestOption
+	if opts.EnableSearch != nil {
+		reqOpts = append(reqOpts, option.WithJSONSet("enable_search", *opts.EnableSearch))
+	}
+	if opts.EnableThinking != nil {
+		reqOpts = append(reqOpts, option.WithJSONSet("enable_thinking", *opts.EnableThinking))
+	}
+	for _, param := range quirks.DropParams {
+		reqOpts = append(reqOpts, option.WithJSONDel(param))
+	}
+
+	return params, reqOpts
+}
+
+// optionsText 序列化消息列表，用于token估算
+func optionsText(opts ChatOptions) string {
+	data, err := json.Marshal(opts.Messages)
+	if err != nil {
+		return ""
+	}
+	return string(data)
 }
 
 // SimpleChat 简单聊天接口
@@ -0,0 +1,314 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sync"
+)
+
+// 文本向量模型
+const (
+	EmbeddingModelV3 = "text-embedding-v3"
+	EmbeddingModelV4 = "text-embedding-v4"
+
+	// DefaultEmbeddingModel 默认的文本向量模型
+	DefaultEmbeddingModel = EmbeddingModelV3
+)
+
+// 向量编码格式
+const (
+	EncodingFormatFloat  = "float"
+	EncodingFormatBase64 = "base64"
+)
+
+// DefaultEmbeddingBatchSize 单次请求的最大文本条数（DashScope 限制为10）
+const DefaultEmbeddingBatchSize = 10
+
+// EmbeddingRequest 文本向量请求
+type EmbeddingRequest struct {
+	Model          string   `json:"model"`
+	Input          []string `json:"input"`
+	Dimensions     int      `json:"dimensions,omitempty"`      // 输出向量维度，0表示使用模型默认值
+	EncodingFormat string   `json:"encoding_format,omitempty"` // float 或 base64，结果都会解码为 []float64
+
+	BatchSize   int `json:"-"` // 每批最大条数，默认 DefaultEmbeddingBatchSize
+	Concurrency int `json:"-"` // 最大并发批次数，默认4
+}
+
+// Embedding 单条文本的向量
+type Embedding struct {
+	Object    string    `json:"object"`
+	Index     int       `json:"index"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// UnmarshalJSON 兼容 float 数组和 base64 编码（小端 float32）两种格式
+func (e *Embedding) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Object    string          `json:"object"`
+		Index     int             `json:"index"`
+		Embedding json.RawMessage `json:"embedding"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	e.Object = raw.Object
+	e.Index = raw.Index
+	e.Embedding = nil
+
+	if len(raw.Embedding) == 0 || raw.Embedding[0] != '"' {
+		return json.Unmarshal(raw.Embedding, &e.Embedding)
+	}
+
+	var encoded string
+	if err := json.Unmarshal(raw.Embedding, &encoded); err != nil {
+		return err
+	}
+	buf, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return fmt.Errorf("decode base64 embedding: %w", err)
+	}
+	if len(buf)%4 != 0 {
+		return fmt.Errorf("invalid base64 embedding length: %d", len(buf))
+	}
+
+	e.Embedding = make([]float64, len(buf)/4)
+	for i := range e.Embedding {
+		e.Embedding[i] = float64(math.Float32frombits(binary.LittleEndian.Uint32(buf[i*4:])))
+	}
+	return nil
+}
+
+// EmbeddingUsage 文本向量的token使用情况
+type EmbeddingUsage struct {
+	PromptTokens int `json:"prompt_tokens"`
+	TotalTokens  int `json:"total_tokens"`
+}
+
+// EmbeddingResponse 文本向量响应
+// 分批请求时 Data 按输入顺序合并，Index 为在原始输入中的位置
+type EmbeddingResponse struct {
+	Object string         `json:"object"`
+	Model  string         `json:"model"`
+	Data   []Embedding    `json:"data"`
+	Usage  EmbeddingUsage `json:"usage"`
+}
+
+// Embeddings 计算文本向量
+// 输入超过批大小时自动拆分为多个请求并发执行，结果按输入顺序重新组装
+func (c *HTTPClient) Embeddings(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	return batchEmbeddings(ctx, req, c.doEmbeddings)
+}
+
+// doEmbeddings 执行一次向量请求
+func (c *HTTPClient) doEmbeddings(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	jsonData, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST",
+		c.config.BaseURL+"/embeddings",
+		bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	c.config.SetHeaders(httpReq.Header)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var embResp EmbeddingResponse
+	if err := json.Unmarshal(body, &embResp); err != nil {
+		return nil, err
+	}
+
+	return &embResp, nil
+}
+
+// Embed 计算单条文本的向量
+func (c *HTTPClient) Embed(ctx context.Context, text string) ([]float64, error) {
+	return embedOne(ctx, text, c.Embeddings)
+}
+
+// Embeddings 计算文本向量，行为与 HTTPClient.Embeddings 相同
+func (c *Client) Embeddings(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+	return batchEmbeddings(ctx, req, func(ctx context.Context, req EmbeddingRequest) (*EmbeddingResponse, error) {
+		// 使用底层客户端的通用接口，以便统一解码 base64 格式
+		var embResp EmbeddingResponse
+		if err := c.client.Post(ctx, "embeddings", req, &embResp); err != nil {
+			return nil, err
+		}
+		return &embResp, nil
+	})
+}
+
+// Embed 计算单条文本的向量
+func (c *Client) Embed(ctx context.Context, text string) ([]float64, error) {
+	return embedOne(ctx, text, c.Embeddings)
+}
+
+func embedOne(ctx context.Context, text string, embeddings func(context.Context, EmbeddingRequest) (*EmbeddingResponse, error)) ([]float64, error) {
+	resp, err := embeddings(ctx, EmbeddingRequest{Input: []string{text}})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp.Data) == 0 {
+		return nil, errors.New("empty embedding response")
+	}
+
+	return resp.Data[0].Embedding, nil
+}
+
+// batchEmbeddings 将输入拆分为多批，以有限并发调用 call 并按输入顺序合并结果
+func batchEmbeddings(ctx context.Context, req EmbeddingRequest, call func(context.Context, EmbeddingRequest) (*EmbeddingResponse, error)) (*EmbeddingResponse, error) {
+	if len(req.Input) == 0 {
+		return nil, errors.New("embedding input is empty")
+	}
+	if req.Model == "" {
+		req.Model = DefaultEmbeddingModel
+	}
+
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultEmbeddingBatchSize
+	}
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = 4
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	numBatches := (len(req.Input) + batchSize - 1) / batchSize
+	results := make([]*EmbeddingResponse, numBatches)
+	sem := make(chan struct{}, concurrency)
+
+	var (
+		wg       sync.WaitGroup
+		once     sync.Once
+		firstErr error
+	)
+	for i := 0; i < numBatches; i++ {
+		start := i * batchSize
+		end := start + batchSize
+		if end > len(req.Input) {
+			end = len(req.Input)
+		}
+
+		batch := req
+		batch.Input = req.Input[start:end]
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(i int, batch EmbeddingRequest) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp, err := call(ctx, batch)
+			if err != nil {
+				once.Do(func() {
+					firstErr = fmt.Errorf("embedding batch %d: %w", i, err)
+					cancel()
+				})
+				return
+			}
+			results[i] = resp
+		}(i, batch)
+	}
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	merged := &EmbeddingResponse{
+		Object: "list",
+		Data:   make([]Embedding, 0, len(req.Input)),
+	}
+	for i, resp := range results {
+		batch, err := orderEmbeddings(resp, batchLen(len(req.Input), batchSize, i))
+		if err != nil {
+			return nil, fmt.Errorf("embedding batch %d: %w", i, err)
+		}
+
+		offset := i * batchSize
+		merged.Model = resp.Model
+		merged.Usage.PromptTokens += resp.Usage.PromptTokens
+		merged.Usage.TotalTokens += resp.Usage.TotalTokens
+		for _, emb := range batch {
+			emb.Index += offset
+			merged.Data = append(merged.Data, emb)
+		}
+	}
+
+	return merged, nil
+}
+
+// batchLen 返回第 i 批的输入条数
+func batchLen(total, batchSize, i int) int {
+	if n := total - i*batchSize; n < batchSize {
+		return n
+	}
+	return batchSize
+}
+
+// orderEmbeddings 校验一批结果并按 Index 排列
+// 批内结果不保证按顺序返回，数量不符、Index 重复或缺失时返回错误
+func orderEmbeddings(resp *EmbeddingResponse, n int) ([]Embedding, error) {
+	if resp == nil {
+		return nil, errors.New("empty embedding response")
+	}
+	if len(resp.Data) != n {
+		return nil, fmt.Errorf("got %d embeddings, want %d", len(resp.Data), n)
+	}
+
+	batch := make([]Embedding, n)
+	seen := make([]bool, n)
+	for _, emb := range resp.Data {
+		if emb.Index < 0 || emb.Index >= n {
+			return nil, fmt.Errorf("index %d out of range", emb.Index)
+		}
+		if seen[emb.Index] {
+			return nil, fmt.Errorf("duplicate index %d", emb.Index)
+		}
+		seen[emb.Index] = true
+		batch[emb.Index] = emb
+	}
+	return batch, nil
+}
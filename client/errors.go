@@ -0,0 +1,104 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/openai/openai-go"
+)
+
+// ErrRateLimited 本地限流器在截止时间前无法获得配额
+var ErrRateLimited = errors.New("rate limited: quota not available before context deadline")
+
+// APIError 接口返回的非200错误
+type APIError struct {
+	StatusCode int
+	Status     string
+	Body       string
+	RetryAfter time.Duration // 服务端通过 Retry-After 建议的等待时间，0表示未提供
+}
+
+// Error 实现 error 接口
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error: %s - %s", e.Status, e.Body)
+}
+
+// newAPIError 从HTTP响应构造错误，会读取响应体
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Body:       string(body),
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+}
+
+// parseRetryAfter 解析 Retry-After 头，支持秒数和HTTP日期两种格式
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(value); err == nil && secs > 0 {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// isTooManyRequests 判断错误是否为429限流错误，并返回建议等待时间
+func isTooManyRequests(err error) (bool, time.Duration) {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests, apiErr.RetryAfter
+	}
+
+	var oaiErr *openai.Error
+	if errors.As(err, &oaiErr) && oaiErr.StatusCode == http.StatusTooManyRequests {
+		var retryAfter time.Duration
+		if oaiErr.Response != nil {
+			retryAfter = parseRetryAfter(oaiErr.Response.Header.Get("Retry-After"))
+		}
+		return true, retryAfter
+	}
+
+	return false, 0
+}
+
+// IsRetryable 判断错误是否可以通过重试或切换后端恢复
+// 包括429、5xx、本地限流以及网络错误；context 取消不视为可重试
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	if errors.Is(err, ErrRateLimited) {
+		return true
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return retryableStatus(apiErr.StatusCode)
+	}
+
+	var oaiErr *openai.Error
+	if errors.As(err, &oaiErr) {
+		return retryableStatus(oaiErr.StatusCode)
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+func retryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code >= http.StatusInternalServerError
+}
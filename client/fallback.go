@@ -0,0 +1,223 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Backend 故障转移链中的一个后端
+type Backend struct {
+	Name   string     // 后端名称，记录在 ResponseMeta.Backend 中；为空时使用模型名称
+	Client ChatClient // 后端使用的客户端，可以是不同接入地址或不同实现的客户端
+	Model  string     // 覆盖请求中的模型，为空时使用请求或客户端配置的模型
+}
+
+// BackendFuncs 由函数构造后端，便于直接接入中间件链或自定义实现
+// stream 为nil时，流式调用通过 chat 获取完整回答后一次性输出
+func BackendFuncs(name string, chat ChatFunc, stream ChatStreamFunc) Backend {
+	return Backend{Name: name, Client: ChatClientFuncs{OnChat: chat, OnStream: stream}}
+}
+
+// StreamResult 流式聊天的结果信息
+type StreamResult struct {
+	Usage *Usage       // 服务端报告的token使用情况（可能为nil）
+	Meta  ResponseMeta // Meta.Backend 记录实际提供流式响应的后端
+}
+
+// FallbackClient 按顺序尝试多个后端的客户端
+// 当某个后端返回可重试的错误（429、5xx、网络错误等）时，自动切换到下一个后端
+type FallbackClient struct {
+	backends []Backend
+
+	// OnFallback 切换后端时的回调，可用于日志记录
+	OnFallback func(from, to string, err error)
+}
+
+// NewFallbackClient 创建故障转移客户端，backends 按优先级排列
+func NewFallbackClient(backends ...Backend) *FallbackClient {
+	return &FallbackClient{backends: backends}
+}
+
+// FallbackError 所有后端均失败时返回的错误
+type FallbackError struct {
+	Attempts []FallbackAttempt // 按尝试顺序记录的失败
+}
+
+// FallbackAttempt 一次失败的后端尝试
+type FallbackAttempt struct {
+	Backend string
+	Err     error
+}
+
+// Error 实现 error 接口
+func (e *FallbackError) Error() string {
+	parts := make([]string, 0, len(e.Attempts))
+	for _, a := range e.Attempts {
+		parts = append(parts, fmt.Sprintf("%s: %v", a.Backend, a.Err))
+	}
+	return "all backends failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap 返回最后一个后端的错误
+func (e *FallbackError) Unwrap() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// Chat 依次尝试各后端发送聊天请求
+// 成功时 ChatResponse.Meta.Backend 记录实际提供响应的后端
+func (f *FallbackClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	var resp *ChatResponse
+	err := f.try(ctx, req, func(b Backend, r ChatRequest, name string) error {
+		var err error
+		resp, err = b.Client.Chat(ctx, r)
+		if err != nil {
+			return err
+		}
+		if resp == nil {
+			return fmt.Errorf("backend %s returned no response", name)
+		}
+		resp.Meta.Backend = name
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// ChatStream 依次尝试各后端进行流式聊天
+// 已经输出部分内容后发生的错误不会再切换后端，直接返回
+func (f *FallbackClient) ChatStream(ctx context.Context, req ChatRequest, handler StreamHandler) error {
+	_, err := f.ChatStreamWithMeta(ctx, req, handler)
+	return err
+}
+
+// ChatStreamWithUsage 流式聊天，返回实际提供响应的后端报告的token使用情况（可能为nil）
+func (f *FallbackClient) ChatStreamWithUsage(ctx context.Context, req ChatRequest, handler StreamHandler) (*Usage, error) {
+	result, err := f.ChatStreamWithMeta(ctx, req, handler)
+	return result.Usage, err
+}
+
+// ChatStreamWithMeta 流式聊天，返回实际提供响应的后端与token使用情况
+// 输出开始后失败时同样返回该后端的信息；返回值不为nil
+func (f *FallbackClient) ChatStreamWithMeta(ctx context.Context, req ChatRequest, handler StreamHandler) (*StreamResult, error) {
+	result := &StreamResult{}
+	err := f.try(ctx, req, func(b Backend, r ChatRequest, name string) error {
+		started := false
+		usage, err := chatStreamWithUsage(ctx, b.Client, r, func(content string) error {
+			started = true
+			return handler(content)
+		})
+		if err == nil || started {
+			result.Usage = usage
+			result.Meta.Backend = name
+		}
+		if err != nil && started {
+			return &streamStartedError{err: err}
+		}
+		return err
+	})
+	return result, err
+}
+
+// chatStreamWithUsage 调用流式接口，客户端支持时返回token使用情况
+func chatStreamWithUsage(ctx context.Context, c ChatClient, req ChatRequest, handler StreamHandler) (*Usage, error) {
+	if us, ok := c.(interface {
+		ChatStreamWithUsage(ctx context.Context, req ChatRequest, handler StreamHandler) (*Usage, error)
+	}); ok {
+		return us.ChatStreamWithUsage(ctx, req, handler)
+	}
+	return nil, c.ChatStream(ctx, req, handler)
+}
+
+// SimpleChat 简单对话
+func (f *FallbackClient) SimpleChat(ctx context.Context, message string) (string, error) {
+	resp, err := f.Chat(ctx, ChatRequest{
+		Messages: []Message{
+			{Role: "user", Content: message},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) > 0 {
+		return resp.Choices[0].Message.Content, nil
+	}
+
+	return "", nil
+}
+
+// try 按顺序执行 call，直到成功、遇到不可重试的错误或所有后端均失败
+func (f *FallbackClient) try(ctx context.Context, req ChatRequest, call func(b Backend, r ChatRequest, name string) error) error {
+	if len(f.backends) == 0 {
+		return errors.New("fallback client has no backends")
+	}
+
+	failures := &FallbackError{}
+	for i, b := range f.backends {
+		r := req
+		if b.Model != "" {
+			r.Model = b.Model
+		}
+		name := backendName(i, b, r)
+
+		err := call(b, r, name)
+		if err == nil {
+			return nil
+		}
+
+		var started *streamStartedError
+		if errors.As(err, &started) {
+			return started.err
+		}
+		if !IsRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		failures.Attempts = append(failures.Attempts, FallbackAttempt{Backend: name, Err: err})
+		if i+1 < len(f.backends) && f.OnFallback != nil {
+			next := f.backends[i+1]
+			nextReq := req
+			if next.Model != "" {
+				nextReq.Model = next.Model
+			}
+			f.OnFallback(name, backendName(i+1, next, nextReq), err)
+		}
+	}
+
+	return failures
+}
+
+// backendName 返回后端的显示名称，依次使用后端名称、模型名称和序号
+func backendName(i int, b Backend, req ChatRequest) string {
+	if b.Name != "" {
+		return b.Name
+	}
+	if req.Model != "" {
+		return req.Model
+	}
+	if hc, ok := b.Client.(*HTTPClient); ok && hc.config.Model != "" {
+		return hc.config.Model
+	}
+	return fmt.Sprintf("backend-%d", i+1)
+}
+
+// streamStartedError 标记流式输出开始后发生的错误
+type streamStartedError struct {
+	err error
+}
+
+func (e *streamStartedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *streamStartedError) Unwrap() error {
+	return e.err
+}
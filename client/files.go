@@ -0,0 +1,137 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// 文件用途
+const (
+	FilePurposeBatch   = "batch"        // Batch 接口的输入文件
+	FilePurposeExtract = "file-extract" // 供 qwen-long 等模型解析的文档
+)
+
+// File 上传的文件
+type File struct {
+	ID        string `json:"id"`
+	Object    string `json:"object"`
+	Bytes     int64  `json:"bytes"`
+	CreatedAt int64  `json:"created_at"`
+	Filename  string `json:"filename"`
+	Purpose   string `json:"purpose"`
+	Status    string `json:"status,omitempty"`
+}
+
+// UploadFile 以 multipart 方式上传文件，内容边读边发，不会整体读入内存
+func (c *HTTPClient) UploadFile(ctx context.Context, filename string, r io.Reader, purpose string) (*File, error) {
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+
+	go func() {
+		err := func() error {
+			if err := mw.WriteField("purpose", purpose); err != nil {
+				return err
+			}
+			part, err := mw.CreateFormFile("file", filename)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(part, r); err != nil {
+				return err
+			}
+			return mw.Close()
+		}()
+		pw.CloseWithError(err)
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.config.BaseURL+"/files", pr)
+	if err != nil {
+		pr.Close()
+		return nil, err
+	}
+
+	c.config.SetHeaders(httpReq.Header)
+	httpReq.Header.Set("Content-Type", mw.FormDataContentType())
+
+	var file File
+	if err := c.doRequest(httpReq, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// ListFiles 列出已上传的文件，purpose 不为空时只返回该用途的文件
+func (c *HTTPClient) ListFiles(ctx context.Context, purpose string) ([]File, error) {
+	u := c.config.BaseURL + "/files"
+	if purpose != "" {
+		u += "?" + url.Values{"purpose": {purpose}}.Encode()
+	}
+
+	var resp struct {
+		Data []File `json:"data"`
+	}
+	if err := c.doJSON(ctx, "GET", u, nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// GetFile 查询文件信息
+func (c *HTTPClient) GetFile(ctx context.Context, id string) (*File, error) {
+	var file File
+	if err := c.doJSON(ctx, "GET", c.config.BaseURL+"/files/"+url.PathEscape(id), nil, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// DeleteFile 删除文件
+func (c *HTTPClient) DeleteFile(ctx context.Context, id string) error {
+	var resp struct {
+		ID      string `json:"id"`
+		Deleted bool   `json:"deleted"`
+	}
+	if err := c.doJSON(ctx, "DELETE", c.config.BaseURL+"/files/"+url.PathEscape(id), nil, &resp); err != nil {
+		return err
+	}
+	if !resp.Deleted {
+		return fmt.Errorf("file %s was not deleted", id)
+	}
+	return nil
+}
+
+// FileContent 下载文件内容，调用方负责关闭返回的 ReadCloser
+func (c *HTTPClient) FileContent(ctx context.Context, id string) (io.ReadCloser, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET",
+		c.config.BaseURL+"/files/"+url.PathEscape(id)+"/content", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	c.config.SetHeaders(httpReq.Header)
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, newAPIError(resp)
+	}
+	return resp.Body, nil
+}
+
+// FileIDMessage 构造引用已上传文件的系统消息，供 qwen-long 等模型解析文档内容
+// 多个文件以逗号分隔，例如 "fileid://file-fe-1,fileid://file-fe-2"
+func FileIDMessage(ids ...string) Message {
+	refs := make([]string, len(ids))
+	for i, id := range ids {
+		refs[i] = "fileid://" + id
+	}
+	return Message{Role: "system", Content: strings.Join(refs, ",")}
+}
@@ -0,0 +1,122 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// FileSession 记录一次会话中上传的文档，会话结束时通过 Close 删除
+//
+// 用法：
+//
+//	fs := client.NewFileSession(c)
+//	defer fs.Close()
+//	fs.Upload(ctx, "report.pdf")
+//	req := client.ChatRequest{Model: "qwen-long", Messages: fs.Messages("你是文档助手", "总结这份报告")}
+type FileSession struct {
+	client *HTTPClient
+
+	mu    sync.Mutex
+	files []File
+}
+
+// NewFileSession 创建文档会话
+func NewFileSession(c *HTTPClient) *FileSession {
+	return &FileSession{client: c}
+}
+
+// Upload 以 file-extract 用途上传本地文档
+func (s *FileSession) Upload(ctx context.Context, path string) (*File, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	file, err := s.client.UploadFile(ctx, filepath.Base(path), f, FilePurposeExtract)
+	if err != nil {
+		return nil, fmt.Errorf("upload %s: %w", path, err)
+	}
+
+	s.mu.Lock()
+	s.files = append(s.files, *file)
+	s.mu.Unlock()
+	return file, nil
+}
+
+// Files 返回本会话上传的文件
+func (s *FileSession) Files() []File {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]File(nil), s.files...)
+}
+
+// FileMessage 返回引用本会话全部文件的系统消息，尚未上传文件时返回false
+func (s *FileSession) FileMessage() (Message, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.files) == 0 {
+		return Message{}, false
+	}
+	ids := make([]string, len(s.files))
+	for i, f := range s.files {
+		ids[i] = f.ID
+	}
+	return FileIDMessage(ids...), true
+}
+
+// Messages 构造 qwen-long 的消息列表：系统提示、文件引用、用户问题
+// system 为空时省略系统提示，尚未上传文件时省略文件引用
+func (s *FileSession) Messages(system, question string) []Message {
+	var messages []Message
+	if system != "" {
+		messages = append(messages, Message{Role: "system", Content: system})
+	}
+	if m, ok := s.FileMessage(); ok {
+		messages = append(messages, m)
+	}
+	return append(messages, Message{Role: "user", Content: question})
+}
+
+// Remove 删除本会话中的单个文件
+func (s *FileSession) Remove(ctx context.Context, id string) error {
+	if err := s.client.DeleteFile(ctx, id); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.files {
+		if f.ID == id {
+			s.files = append(s.files[:i], s.files[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Close 删除本会话上传的全部文件，删除失败的文件保留在会话中，可以再次调用 Close
+func (s *FileSession) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	var remaining []File
+	for _, f := range s.files {
+		if err := s.client.DeleteFile(ctx, f.ID); err != nil {
+			errs = append(errs, fmt.Errorf("delete %s: %w", f.ID, err))
+			remaining = append(remaining, f)
+		}
+	}
+	s.files = remaining
+	return errors.Join(errs...)
+}
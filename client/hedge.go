@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// HedgeConfig 对冲请求配置
+type HedgeConfig struct {
+	// Percentile 用于计算对冲延迟的延迟分位数，默认0.95
+	Percentile float64
+	// MinDelay/MaxDelay 对冲延迟的上下限，默认100ms和10s
+	MinDelay time.Duration
+	MaxDelay time.Duration
+	// InitialDelay 观测样本不足时使用的对冲延迟，默认2s
+	InitialDelay time.Duration
+	// MaxHedgeRatio 对冲请求占总请求数的最大比例，用于限制额外负载，默认0.1
+	MaxHedgeRatio float64
+	// WindowSize 保留的延迟样本数量，默认200
+	WindowSize int
+}
+
+// hedger 记录观测到的延迟，决定何时发出对冲请求
+type hedger struct {
+	config HedgeConfig
+
+	mu       sync.Mutex
+	samples  []time.Duration
+	next     int
+	requests int
+	hedges   int
+}
+
+// newHedger 创建对冲控制器
+func newHedger(cfg HedgeConfig) *hedger {
+	if cfg.Percentile <= 0 || cfg.Percentile >= 1 {
+		cfg.Percentile = 0.95
+	}
+	if cfg.MinDelay <= 0 {
+		cfg.MinDelay = 100 * time.Millisecond
+	}
+	if cfg.MaxDelay <= 0 {
+		cfg.MaxDelay = 10 * time.Second
+	}
+	if cfg.InitialDelay <= 0 {
+		cfg.InitialDelay = 2 * time.Second
+	}
+	if cfg.MaxHedgeRatio <= 0 {
+		cfg.MaxHedgeRatio = 0.1
+	}
+	if cfg.WindowSize <= 0 {
+		cfg.WindowSize = 200
+	}
+
+	return &hedger{
+		config:  cfg,
+		samples: make([]time.Duration, 0, cfg.WindowSize),
+	}
+}
+
+// observe 记录一次成功请求的延迟
+func (h *hedger) observe(d time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if len(h.samples) < h.config.WindowSize {
+		h.samples = append(h.samples, d)
+		return
+	}
+	h.samples[h.next] = d
+	h.next = (h.next + 1) % h.config.WindowSize
+}
+
+// delay 根据观测样本计算当前的对冲延迟
+func (h *hedger) delay() time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// 样本太少时分位数没有意义
+	if len(h.samples) < 10 {
+		return h.config.InitialDelay
+	}
+
+	sorted := make([]time.Duration, len(h.samples))
+	copy(sorted, h.samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	d := sorted[int(float64(len(sorted)-1)*h.config.Percentile)]
+	if d < h.config.MinDelay {
+		d = h.config.MinDelay
+	}
+	if d > h.config.MaxDelay {
+		d = h.config.MaxDelay
+	}
+	return d
+}
+
+// start 记录一次请求
+func (h *hedger) start() {
+	h.mu.Lock()
+	h.requests++
+	h.mu.Unlock()
+}
+
+// allowHedge 判断是否还有对冲配额，允许时计入对冲次数
+func (h *hedger) allowHedge() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if float64(h.hedges+1) > float64(h.requests)*h.config.MaxHedgeRatio {
+		return false
+	}
+	h.hedges++
+	return true
+}
+
+// HedgeStats 对冲统计
+type HedgeStats struct {
+	Requests int           // 总请求数
+	Hedges   int           // 发出的对冲请求数
+	Delay    time.Duration // 当前对冲延迟
+}
+
+// stats 返回对冲统计
+func (h *hedger) stats() HedgeStats {
+	d := h.delay()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return HedgeStats{Requests: h.requests, Hedges: h.hedges, Delay: d}
+}
+
+// hedgeResult 单个请求的结果
+type hedgeResult struct {
+	resp   *ChatResponse
+	err    error
+	hedged bool
+}
+
+// do 执行请求，超过对冲延迟仍未返回时发出第二个相同的请求，取先完成的结果
+func (h *hedger) do(ctx context.Context, call func(ctx context.Context) (*ChatResponse, error)) (*ChatResponse, error) {
+	h.start()
+
+	ctx, cancel := context.WithCancel(ctx)
+	// 返回时取消仍在进行的请求
+	defer cancel()
+
+	results := make(chan hedgeResult, 2)
+	launch := func(hedged bool) {
+		go func() {
+			begin := time.Now()
+			resp, err := call(ctx)
+			if err == nil {
+				h.observe(time.Since(begin))
+			}
+			results <- hedgeResult{resp: resp, err: err, hedged: hedged}
+		}()
+	}
+
+	launch(false)
+	inflight := 1
+
+	timer := time.NewTimer(h.delay())
+	defer timer.Stop()
+
+	var firstErr error
+	for {
+		select {
+		case <-timer.C:
+			if h.allowHedge() {
+				launch(true)
+				inflight++
+			}
+		case r := <-results:
+			inflight--
+			if r.err == nil {
+				r.resp.Meta.Hedged = r.hedged
+				return r.resp, nil
+			}
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			if inflight == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"github.com/lvdashuaibi/GPTUtils/config"
+	"github.com/lvdashuaibi/GPTUtils/models"
 	"io"
 	"net/http"
 	"strings"
@@ -17,6 +17,11 @@ import (
 type HTTPClient struct {
 	config     *config.Config
 	httpClient *http.Client
+	limiter    *RateLimiter
+	hedger     *hedger
+	registry   *models.Registry
+
+	middlewares []Middleware
 }
 
 // NewHTTPClient 创建HTTP客户端
@@ -29,16 +34,87 @@ func NewHTTPClient(cfg *config.Config) *HTTPClient {
 	return &HTTPClient{
 		config:     cfg,
 		httpClient: &http.Client{},
+		registry:   cfg.ModelRegistry(),
 	}
 }
 
+// WithModelRegistry 设置校验请求使用的模型注册表，默认为 cfg.ModelRegistry()
+func (c *HTTPClient) WithModelRegistry(registry *models.Registry) *HTTPClient {
+	c.registry = registry
+	return c
+}
+
+// WithRateLimiter 设置客户端限流器，nil表示不限流
+// 同一个限流器可以在多个客户端之间共享
+func (c *HTTPClient) WithRateLimiter(limiter *RateLimiter) *HTTPClient {
+	c.limiter = limiter
+	return c
+}
+
+// WithHedging 为 Chat 开启对冲请求
+// 超过按观测延迟分位数计算的等待时间仍未返回时，发出第二个相同请求并取先返回的结果。
+// 对冲请求不经过限流器计数，额外负载由 HedgeConfig.MaxHedgeRatio 限制
+func (c *HTTPClient) WithHedging(cfg HedgeConfig) *HTTPClient {
+	c.hedger = newHedger(cfg)
+	return c
+}
+
+// HedgeStats 返回对冲统计，未开启对冲时返回零值
+func (c *HTTPClient) HedgeStats() HedgeStats {
+	if c.hedger == nil {
+		return HedgeStats{}
+	}
+	return c.hedger.stats()
+}
+
 // Chat 发送聊天请求
 func (c *HTTPClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
 	if req.Model == "" {
 		req.Model = c.config.Model
 	}
+	req.Model = c.config.ResolveModel(req.Model)
+	if err := c.validate(req); err != nil {
+		return nil, err
+	}
 
-	jsonData, err := json.Marshal(req)
+	return c.chatChain()(ctx, req)
+}
+
+// chat 在限流、对冲保护下执行请求，位于中间件调用链的最内层
+func (c *HTTPClient) chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if c.limiter == nil {
+		return c.hedgedChat(ctx, req)
+	}
+
+	var chatResp *ChatResponse
+	err := c.limiter.run(ctx, req.Model, messagesText(req.Messages), func() (*Usage, error) {
+		resp, err := c.hedgedChat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		chatResp = resp
+		return &resp.Usage, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return chatResp, nil
+}
+
+// hedgedChat 开启对冲时通过对冲控制器执行请求
+func (c *HTTPClient) hedgedChat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if c.hedger == nil {
+		return c.doChat(ctx, req)
+	}
+	return c.hedger.do(ctx, func(ctx context.Context) (*ChatResponse, error) {
+		return c.doChat(ctx, req)
+	})
+}
+
+// doChat 执行一次非流式请求
+func (c *HTTPClient) doChat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	jsonData, err := c.encodeRequest(req)
 	if err != nil {
 		return nil, err
 	}
@@ -50,7 +126,7 @@ func (c *HTTPClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse,
 		return nil, err
 	}
 
-	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	c.config.SetHeaders(httpReq.Header)
 	httpReq.Header.Set("Content-Type", "application/json")
 
 	resp, err := c.httpClient.Do(httpReq)
@@ -60,8 +136,7 @@ func (c *HTTPClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse,
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+		return nil, newAPIError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -78,39 +153,72 @@ func (c *HTTPClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse,
 }
 
 // ChatStream 流式聊天
-func (c *HTTPClient) ChatStream(ctx context.Context, req ChatRequest, handler func(content string) error) error {
+func (c *HTTPClient) ChatStream(ctx context.Context, req ChatRequest, handler StreamHandler) error {
+	_, err := c.ChatStreamWithUsage(ctx, req, handler)
+	return err
+}
+
+// ChatStreamWithUsage 流式聊天，返回服务端报告的token使用情况（可能为nil）
+func (c *HTTPClient) ChatStreamWithUsage(ctx context.Context, req ChatRequest, handler StreamHandler) (*Usage, error) {
 	if req.Model == "" {
 		req.Model = c.config.Model
 	}
+	req.Model = c.config.ResolveModel(req.Model)
+	if err := c.validate(req); err != nil {
+		return nil, err
+	}
 	req.Stream = true
+	if !c.config.ProviderQuirks().NoStreamUsage {
+		req.StreamOptions = &StreamOptions{IncludeUsage: true}
+	}
 
-	jsonData, err := json.Marshal(req)
+	return c.chatStreamChain()(ctx, req, handler)
+}
+
+// chatStream 在限流保护下执行流式请求，位于中间件调用链的最内层
+func (c *HTTPClient) chatStream(ctx context.Context, req ChatRequest, handler StreamHandler) (*Usage, error) {
+	if c.limiter == nil {
+		return c.doChatStream(ctx, req, handler)
+	}
+
+	var usage *Usage
+	err := c.limiter.run(ctx, req.Model, messagesText(req.Messages), func() (*Usage, error) {
+		var err error
+		usage, err = c.doChatStream(ctx, req, handler)
+		return usage, err
+	})
+	return usage, err
+}
+
+// doChatStream 执行一次流式请求，返回服务端报告的token使用情况（可能为nil）
+func (c *HTTPClient) doChatStream(ctx context.Context, req ChatRequest, handler func(content string) error) (*Usage, error) {
+	jsonData, err := c.encodeRequest(req)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	httpReq, err := http.NewRequestWithContext(ctx, "POST",
 		c.config.BaseURL+"/chat/completions",
 		bytes.NewBuffer(jsonData))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+	c.config.SetHeaders(httpReq.Header)
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Accept", "text/event-stream")
 
 	resp, err := c.httpClient.Do(httpReq)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error: %s - %s", resp.Status, string(body))
+		return nil, newAPIError(resp)
 	}
 
+	var usage *Usage
 	reader := bufio.NewReader(resp.Body)
 	for {
 		line, err := reader.ReadString('\n')
@@ -118,7 +226,7 @@ func (c *HTTPClient) ChatStream(ctx context.Context, req ChatRequest, handler fu
 			if err == io.EOF {
 				break
 			}
-			return err
+			return usage, err
 		}
 
 		line = strings.TrimSpace(line)
@@ -134,15 +242,19 @@ func (c *HTTPClient) ChatStream(ctx context.Context, req ChatRequest, handler fu
 				continue
 			}
 
+			if chunk.Usage != nil {
+				usage = chunk.Usage
+			}
+
 			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
 				if err := handler(chunk.Choices[0].Delta.Content); err != nil {
-					return err
+					return usage, err
 				}
 			}
 		}
 	}
 
-	return nil
+	return usage, nil
 }
 
 // SimpleChat 简单对话
@@ -175,3 +287,79 @@ func (c *HTTPClient) SimpleChatStream(ctx context.Context, message string, handl
 
 	return c.ChatStream(ctx, req, handler)
 }
+
+// messagesText 拼接消息内容，用于token估算
+func messagesText(messages []Message) string {
+	var sb strings.Builder
+	for _, msg := range messages {
+		sb.WriteString(msg.Content)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// encodeRequest 序列化请求，并按服务商的协议差异调整参数
+func (c *HTTPClient) encodeRequest(req ChatRequest) ([]byte, error) {
+	quirks := c.config.ProviderQuirks()
+	req.Temperature = clampTemperature(req.Temperature, quirks)
+
+	jsonData, err := json.Marshal(req)
+	if err != nil || len(quirks.DropParams) == 0 {
+		return jsonData, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(jsonData, &fields); err != nil {
+		return nil, err
+	}
+	for _, param := range quirks.DropParams {
+		delete(fields, param)
+	}
+	return json.Marshal(fields)
+}
+
+// doJSON 发送JSON请求并解析JSON响应，body 和 out 可以为nil
+func (c *HTTPClient) doJSON(ctx context.Context, method, url string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewBuffer(jsonData)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+
+	c.config.SetHeaders(httpReq.Header)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.doRequest(httpReq, out)
+}
+
+// doRequest 发送请求并解析JSON响应，out 为nil时忽略响应体
+func (c *HTTPClient) doRequest(httpReq *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return newAPIError(resp)
+	}
+	if out == nil {
+		return nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(respBody, out)
+}
@@ -0,0 +1,189 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+)
+
+// DefaultImageModel 默认的文生图模型
+const DefaultImageModel = "wanx2.1-t2i-turbo"
+
+// imageSynthesisPath 文生图接口路径
+const imageSynthesisPath = "/services/aigc/text2image/image-synthesis"
+
+// ImageRequest 文生图请求
+type ImageRequest struct {
+	Model          string // 模型，默认 DefaultImageModel
+	Prompt         string // 正向提示词
+	NegativePrompt string // 反向提示词
+	Size           string // 图像尺寸，格式为 "宽*高"，例如 "1024*1024"
+	N              int    // 生成数量，默认1
+	Seed           *int   // 随机种子
+	PromptExtend   *bool  // 是否由模型改写提示词
+	Watermark      *bool  // 是否添加水印
+}
+
+// ImageResult 单张图像的结果
+type ImageResult struct {
+	URL          string `json:"url,omitempty"`
+	OrigPrompt   string `json:"orig_prompt,omitempty"`
+	ActualPrompt string `json:"actual_prompt,omitempty"` // 开启提示词改写时实际使用的提示词
+	Code         string `json:"code,omitempty"`          // 单张图像失败时的错误码
+	Message      string `json:"message,omitempty"`
+}
+
+// ImageResponse 文生图结果，图像URL有效期为24小时
+type ImageResponse struct {
+	TaskID  string
+	Results []ImageResult
+	Usage   struct {
+		ImageCount int `json:"image_count"`
+	}
+}
+
+// SubmitImage 提交文生图任务，返回的任务可通过 WaitTask 等待、ParseImageTask 解析
+func (c *HTTPClient) SubmitImage(ctx context.Context, req ImageRequest) (*Task, error) {
+	if req.Prompt == "" {
+		return nil, errors.New("image prompt is empty")
+	}
+	return c.SubmitTask(ctx, imageSynthesisPath, imagePayload(req))
+}
+
+// GenerateImage 提交文生图任务并等待结果
+func (c *HTTPClient) GenerateImage(ctx context.Context, req ImageRequest) (*ImageResponse, error) {
+	task, err := c.SubmitImage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	task, err = c.WaitTask(ctx, task.ID, TaskPoll{})
+	if err != nil {
+		return nil, err
+	}
+	return ParseImageTask(task)
+}
+
+// imagePayload 构造文生图接口的请求体
+func imagePayload(req ImageRequest) map[string]interface{} {
+	if req.Model == "" {
+		req.Model = DefaultImageModel
+	}
+
+	input := map[string]interface{}{"prompt": req.Prompt}
+	if req.NegativePrompt != "" {
+		input["negative_prompt"] = req.NegativePrompt
+	}
+
+	parameters := map[string]interface{}{}
+	if req.Size != "" {
+		parameters["size"] = req.Size
+	}
+	if req.N > 0 {
+		parameters["n"] = req.N
+	}
+	if req.Seed != nil {
+		parameters["seed"] = *req.Seed
+	}
+	if req.PromptExtend != nil {
+		parameters["prompt_extend"] = *req.PromptExtend
+	}
+	if req.Watermark != nil {
+		parameters["watermark"] = *req.Watermark
+	}
+
+	return map[string]interface{}{
+		"model":      req.Model,
+		"input":      input,
+		"parameters": parameters,
+	}
+}
+
+// ParseImageTask 从已成功的文生图任务中解析结果
+func ParseImageTask(task *Task) (*ImageResponse, error) {
+	if err := task.Err(); err != nil {
+		return nil, err
+	}
+
+	var output struct {
+		Results []ImageResult `json:"results"`
+	}
+	if err := json.Unmarshal(task.Output, &output); err != nil {
+		return nil, err
+	}
+
+	resp := &ImageResponse{TaskID: task.ID, Results: output.Results}
+	if len(task.Usage) > 0 {
+		if err := json.Unmarshal(task.Usage, &resp.Usage); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// DownloadImages 将生成的图像下载到 dir 目录，文件名为 任务ID_序号.扩展名
+// 返回与 Results 一一对应的本地路径，失败的图像对应空字符串
+func (c *HTTPClient) DownloadImages(ctx context.Context, resp *ImageResponse, dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	paths := make([]string, len(resp.Results))
+	for i, r := range resp.Results {
+		if r.URL == "" {
+			continue
+		}
+
+		ext := ".png"
+		if u, err := url.Parse(r.URL); err == nil && path.Ext(u.Path) != "" {
+			ext = path.Ext(u.Path)
+		}
+		dst := filepath.Join(dir, resp.TaskID+"_"+strconv.Itoa(i+1)+ext)
+		if err := c.download(ctx, r.URL, dst); err != nil {
+			return paths, fmt.Errorf("download image %d: %w", i+1, err)
+		}
+		paths[i] = dst
+	}
+	return paths, nil
+}
+
+// download 将URL内容保存到本地文件，结果URL自带签名，不需要鉴权头
+func (c *HTTPClient) download(ctx context.Context, rawURL, dst string) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return newAPIError(resp)
+	}
+
+	tmp := dst + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
@@ -0,0 +1,40 @@
+package client
+
+import "context"
+
+// ChatFunc 非流式聊天调用
+type ChatFunc func(ctx context.Context, req ChatRequest) (*ChatResponse, error)
+
+// ChatStreamFunc 流式聊天调用，返回服务端报告的token使用情况（可能为nil）
+type ChatStreamFunc func(ctx context.Context, req ChatRequest, handler StreamHandler) (*Usage, error)
+
+// Middleware HTTPClient 中间件
+// 中间件在限流、对冲之外执行，收到的请求已经填充了默认模型
+type Middleware interface {
+	WrapChat(next ChatFunc) ChatFunc
+	WrapChatStream(next ChatStreamFunc) ChatStreamFunc
+}
+
+// Use 注册中间件，先注册的中间件位于调用链的最外层
+func (c *HTTPClient) Use(middlewares ...Middleware) *HTTPClient {
+	c.middlewares = append(c.middlewares, middlewares...)
+	return c
+}
+
+// chatChain 构造包含中间件的非流式调用链
+func (c *HTTPClient) chatChain() ChatFunc {
+	chain := ChatFunc(c.chat)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		chain = c.middlewares[i].WrapChat(chain)
+	}
+	return chain
+}
+
+// chatStreamChain 构造包含中间件的流式调用链
+func (c *HTTPClient) chatStreamChain() ChatStreamFunc {
+	chain := ChatStreamFunc(c.chatStream)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		chain = c.middlewares[i].WrapChatStream(chain)
+	}
+	return chain
+}
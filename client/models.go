@@ -0,0 +1,99 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/lvdashuaibi/GPTUtils/models"
+)
+
+// ModelInfo 模型信息，由 /models 接口返回的数据与本地模型注册表合并得到
+type ModelInfo struct {
+	ID      string `json:"id"`
+	Object  string `json:"object,omitempty"`
+	Created int64  `json:"created,omitempty"`
+	OwnedBy string `json:"owned_by,omitempty"`
+
+	// 以下字段来自本地模型注册表，Registered 为false时均为零值
+	Registered      bool              `json:"registered"`
+	ContextWindow   int               `json:"context_window,omitempty"`
+	MaxInputTokens  int               `json:"max_input_tokens,omitempty"`
+	MaxOutputTokens int               `json:"max_output_tokens,omitempty"`
+	Capabilities    models.Capability `json:"capabilities"`
+}
+
+// mergeModelInfo 用注册表中的元数据补全模型信息
+func mergeModelInfo(registry *models.Registry, info ModelInfo) ModelInfo {
+	m, ok := registry.Lookup(info.ID)
+	if !ok {
+		return info
+	}
+
+	info.Registered = true
+	info.ContextWindow = m.ContextWindow
+	info.MaxInputTokens = m.InputLimit()
+	info.MaxOutputTokens = m.MaxOutputTokens
+	info.Capabilities = m.Capabilities
+	return info
+}
+
+// ListModels 列出当前账号可用的模型
+func (c *HTTPClient) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var resp struct {
+		Data []ModelInfo `json:"data"`
+	}
+	if err := c.doJSON(ctx, "GET", c.config.BaseURL+"/models", nil, &resp); err != nil {
+		return nil, err
+	}
+
+	for i, info := range resp.Data {
+		resp.Data[i] = mergeModelInfo(c.registry, info)
+	}
+	return resp.Data, nil
+}
+
+// GetModel 获取单个模型的信息
+func (c *HTTPClient) GetModel(ctx context.Context, id string) (*ModelInfo, error) {
+	var info ModelInfo
+	if err := c.doJSON(ctx, "GET", c.config.BaseURL+"/models/"+url.PathEscape(id), nil, &info); err != nil {
+		return nil, err
+	}
+
+	info = mergeModelInfo(c.registry, info)
+	return &info, nil
+}
+
+// ListModels 列出当前账号可用的模型
+func (c *Client) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	page, err := c.client.Models.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ModelInfo, len(page.Data))
+	for i, m := range page.Data {
+		infos[i] = mergeModelInfo(c.registry, ModelInfo{
+			ID:      m.ID,
+			Object:  string(m.Object),
+			Created: m.Created,
+			OwnedBy: m.OwnedBy,
+		})
+	}
+	return infos, nil
+}
+
+// GetModel 获取单个模型的信息
+func (c *Client) GetModel(ctx context.Context, id string) (*ModelInfo, error) {
+	m, err := c.client.Models.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	info := mergeModelInfo(c.registry, ModelInfo{
+		ID:      m.ID,
+		Object:  string(m.Object),
+		Created: m.Created,
+		OwnedBy: m.OwnedBy,
+	})
+	return &info, nil
+}
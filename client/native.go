@@ -0,0 +1,444 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/config"
+	"github.com/lvdashuaibi/GPTUtils/models"
+)
+
+// nativeGenerationPath 原生文本生成接口路径
+const nativeGenerationPath = "/services/aigc/text-generation/generation"
+
+// DashScopeParams DashScope 原生协议独有的参数
+type DashScopeParams struct {
+	TopK              *int                   // 采样候选集大小，取值大于0
+	RepetitionPenalty *float64               // 重复惩罚，1.0表示不惩罚，取值大于0
+	SearchOptions     *NativeSearchOptions   // 联网搜索策略，需同时设置 EnableSearch
+	Extra             map[string]interface{} // 其他原生参数，原样放入 parameters
+}
+
+// NativeSearchOptions 原生协议的联网搜索策略
+type NativeSearchOptions struct {
+	EnableSource   bool   `json:"enable_source,omitempty"`   // 在响应中返回搜索来源
+	EnableCitation bool   `json:"enable_citation,omitempty"` // 在回答中标注引用角标
+	CitationFormat string `json:"citation_format,omitempty"` // 角标格式，如 "[<number>]"
+	ForcedSearch   bool   `json:"forced_search,omitempty"`   // 强制搜索
+	SearchStrategy string `json:"search_strategy,omitempty"` // standard 或 pro
+}
+
+// SearchInfo 联网搜索来源
+type SearchInfo struct {
+	SearchResults []SearchResult `json:"search_results"`
+}
+
+// SearchResult 单条搜索来源
+type SearchResult struct {
+	Index    int    `json:"index"`
+	Title    string `json:"title"`
+	URL      string `json:"url"`
+	SiteName string `json:"site_name"`
+	Icon     string `json:"icon,omitempty"`
+}
+
+// NativeClient 基于 DashScope 原生协议的客户端
+// 与 HTTPClient 使用相同的请求、响应类型与中间件，额外发送 ChatRequest.DashScope 中的原生参数
+type NativeClient struct {
+	config     *config.Config
+	httpClient *http.Client
+	limiter    *RateLimiter
+	registry   *models.Registry
+
+	middlewares []Middleware
+}
+
+// NewNativeClient 创建原生协议客户端
+// cfg: 配置对象，如果为nil则使用默认配置；接口地址由 cfg.DashScopeBaseURL() 决定
+func NewNativeClient(cfg *config.Config) *NativeClient {
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+
+	return &NativeClient{
+		config:     cfg,
+		httpClient: &http.Client{},
+		registry:   cfg.ModelRegistry(),
+	}
+}
+
+// WithModelRegistry 设置校验请求使用的模型注册表，默认为 cfg.ModelRegistry()
+func (c *NativeClient) WithModelRegistry(registry *models.Registry) *NativeClient {
+	c.registry = registry
+	return c
+}
+
+// WithRateLimiter 设置客户端限流器，nil表示不限流
+func (c *NativeClient) WithRateLimiter(limiter *RateLimiter) *NativeClient {
+	c.limiter = limiter
+	return c
+}
+
+// Use 注册中间件，先注册的中间件位于调用链的最外层
+func (c *NativeClient) Use(middlewares ...Middleware) *NativeClient {
+	c.middlewares = append(c.middlewares, middlewares...)
+	return c
+}
+
+// Chat 发送聊天请求
+func (c *NativeClient) Chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if req.Model == "" {
+		req.Model = c.config.Model
+	}
+	req.Model = c.config.ResolveModel(req.Model)
+	if err := c.validate(req); err != nil {
+		return nil, err
+	}
+
+	chain := ChatFunc(c.chat)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		chain = c.middlewares[i].WrapChat(chain)
+	}
+	return chain(ctx, req)
+}
+
+// ChatStream 流式聊天
+func (c *NativeClient) ChatStream(ctx context.Context, req ChatRequest, handler StreamHandler) error {
+	_, err := c.ChatStreamWithUsage(ctx, req, handler)
+	return err
+}
+
+// ChatStreamWithUsage 流式聊天，返回服务端报告的token使用情况（可能为nil）
+func (c *NativeClient) ChatStreamWithUsage(ctx context.Context, req ChatRequest, handler StreamHandler) (*Usage, error) {
+	if req.Model == "" {
+		req.Model = c.config.Model
+	}
+	req.Model = c.config.ResolveModel(req.Model)
+	if err := c.validate(req); err != nil {
+		return nil, err
+	}
+	req.Stream = true
+
+	chain := ChatStreamFunc(c.chatStream)
+	for i := len(c.middlewares) - 1; i >= 0; i-- {
+		chain = c.middlewares[i].WrapChatStream(chain)
+	}
+	return chain(ctx, req, handler)
+}
+
+// SimpleChat 简单对话
+func (c *NativeClient) SimpleChat(ctx context.Context, message string) (string, error) {
+	resp, err := c.Chat(ctx, ChatRequest{
+		Messages: []Message{{Role: "user", Content: message}},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.Choices) > 0 {
+		return resp.Choices[0].Message.Content, nil
+	}
+	return "", nil
+}
+
+// SimpleChatStream 简单流式对话
+func (c *NativeClient) SimpleChatStream(ctx context.Context, message string, handler func(content string) error) error {
+	return c.ChatStream(ctx, ChatRequest{
+		Messages: []Message{{Role: "user", Content: message}},
+	}, handler)
+}
+
+// validate 校验请求参数，原生参数的范围在此额外检查；服务商不支持原生接口时返回错误
+func (c *NativeClient) validate(req ChatRequest) error {
+	if err := c.config.CheckDashScope(); err != nil {
+		return err
+	}
+	if p := req.DashScope; p != nil {
+		if p.TopK != nil && *p.TopK <= 0 {
+			return &ValidationError{Model: req.Model, Param: "top_k", Reason: "must be positive"}
+		}
+		if p.RepetitionPenalty != nil && *p.RepetitionPenalty <= 0 {
+			return &ValidationError{Model: req.Model, Param: "repetition_penalty", Reason: "must be positive"}
+		}
+	}
+	return validate(c.registry, req.Model, chatRequestFeatures(req), c.config.ProviderQuirks())
+}
+
+// chat 在限流保护下执行请求，位于中间件调用链的最内层
+func (c *NativeClient) chat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	if c.limiter == nil {
+		return c.doChat(ctx, req)
+	}
+
+	var chatResp *ChatResponse
+	err := c.limiter.run(ctx, req.Model, messagesText(req.Messages), func() (*Usage, error) {
+		resp, err := c.doChat(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		chatResp = resp
+		return &resp.Usage, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return chatResp, nil
+}
+
+// chatStream 在限流保护下执行流式请求，位于中间件调用链的最内层
+func (c *NativeClient) chatStream(ctx context.Context, req ChatRequest, handler StreamHandler) (*Usage, error) {
+	if c.limiter == nil {
+		return c.doChatStream(ctx, req, handler)
+	}
+
+	var usage *Usage
+	err := c.limiter.run(ctx, req.Model, messagesText(req.Messages), func() (*Usage, error) {
+		var err error
+		usage, err = c.doChatStream(ctx, req, handler)
+		return usage, err
+	})
+	return usage, err
+}
+
+// newRequest 构造原生协议的HTTP请求
+func (c *NativeClient) newRequest(ctx context.Context, req ChatRequest) (*http.Request, error) {
+	jsonData, err := json.Marshal(nativePayload(req))
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST",
+		c.config.DashScopeBaseURL()+nativeGenerationPath,
+		bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	c.config.SetHeaders(httpReq.Header)
+	httpReq.Header.Set("Content-Type", "application/json")
+	if req.Stream {
+		httpReq.Header.Set("Accept", "text/event-stream")
+		httpReq.Header.Set("X-DashScope-SSE", "enable")
+	}
+	return httpReq, nil
+}
+
+// doChat 执行一次非流式请求
+func (c *NativeClient) doChat(ctx context.Context, req ChatRequest) (*ChatResponse, error) {
+	httpReq, err := c.newRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var nativeResp nativeResponse
+	if err := json.Unmarshal(body, &nativeResp); err != nil {
+		return nil, err
+	}
+
+	return nativeResp.chatResponse(req.Model), nil
+}
+
+// doChatStream 执行一次流式请求，使用增量输出，每个事件只包含新生成的内容
+func (c *NativeClient) doChatStream(ctx context.Context, req ChatRequest, handler StreamHandler) (*Usage, error) {
+	httpReq, err := c.newRequest(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	// 事件格式：
+	//   id:1
+	//   event:result
+	//   :HTTP_STATUS/200
+	//   data:{"output":{...},"usage":{...},"request_id":"..."}
+	var usage *Usage
+	event, status := "", http.StatusOK
+	reader := bufio.NewReader(resp.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return usage, err
+		}
+
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			event, status = "", http.StatusOK
+		case strings.HasPrefix(line, "event:"):
+			event = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, ":HTTP_STATUS/"):
+			if code, err := strconv.Atoi(strings.TrimPrefix(line, ":HTTP_STATUS/")); err == nil {
+				status = code
+			}
+		case strings.HasPrefix(line, "data:"):
+			data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+			if event == "error" {
+				return usage, &APIError{StatusCode: status, Status: strconv.Itoa(status) + " " + http.StatusText(status), Body: data}
+			}
+
+			var chunk nativeResponse
+			if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+				return usage, fmt.Errorf("parse stream chunk: %w", err)
+			}
+
+			if chunk.Usage.TotalTokens > 0 || chunk.Usage.InputTokens > 0 {
+				usage = chunk.usage()
+			}
+
+			if len(chunk.Output.Choices) > 0 && chunk.Output.Choices[0].Message.Content != "" {
+				if err := handler(chunk.Output.Choices[0].Message.Content); err != nil {
+					return usage, err
+				}
+			}
+		}
+	}
+
+	return usage, nil
+}
+
+// nativePayload 将请求转换为原生协议的 input/parameters 结构
+func nativePayload(req ChatRequest) map[string]interface{} {
+	parameters := map[string]interface{}{
+		"result_format": "message",
+	}
+	if req.Stream {
+		parameters["incremental_output"] = true
+	}
+	if req.Temperature != nil {
+		parameters["temperature"] = *req.Temperature
+	}
+	if req.TopP != nil {
+		parameters["top_p"] = *req.TopP
+	}
+	if req.MaxTokens != nil {
+		parameters["max_tokens"] = *req.MaxTokens
+	}
+	if req.PresencePenalty != nil {
+		parameters["presence_penalty"] = *req.PresencePenalty
+	}
+	if req.Seed != nil {
+		parameters["seed"] = *req.Seed
+	}
+	if len(req.Stop) > 0 {
+		parameters["stop"] = req.Stop
+	}
+	if req.ResponseFormat != nil {
+		parameters["response_format"] = req.ResponseFormat
+	}
+	if req.EnableThinking != nil {
+		parameters["enable_thinking"] = *req.EnableThinking
+	}
+	if req.EnableSearch != nil {
+		parameters["enable_search"] = *req.EnableSearch
+	}
+
+	if p := req.DashScope; p != nil {
+		if p.TopK != nil {
+			parameters["top_k"] = *p.TopK
+		}
+		if p.RepetitionPenalty != nil {
+			parameters["repetition_penalty"] = *p.RepetitionPenalty
+		}
+		if p.SearchOptions != nil {
+			parameters["search_options"] = p.SearchOptions
+		}
+		for k, v := range p.Extra {
+			parameters[k] = v
+		}
+	}
+
+	return map[string]interface{}{
+		"model":      req.Model,
+		"input":      map[string]interface{}{"messages": req.Messages},
+		"parameters": parameters,
+	}
+}
+
+// nativeResponse 原生协议的响应，流式事件的 data 结构相同
+type nativeResponse struct {
+	RequestID string `json:"request_id"`
+	Output    struct {
+		Choices []struct {
+			FinishReason string  `json:"finish_reason"`
+			Message      Message `json:"message"`
+		} `json:"choices"`
+		SearchInfo *SearchInfo `json:"search_info"`
+	} `json:"output"`
+	Usage struct {
+		InputTokens         int                  `json:"input_tokens"`
+		OutputTokens        int                  `json:"output_tokens"`
+		TotalTokens         int                  `json:"total_tokens"`
+		PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details"`
+	} `json:"usage"`
+}
+
+// usage 转换为兼容模式的token使用情况
+func (r *nativeResponse) usage() *Usage {
+	u := &Usage{
+		PromptTokens:        r.Usage.InputTokens,
+		CompletionTokens:    r.Usage.OutputTokens,
+		TotalTokens:         r.Usage.TotalTokens,
+		PromptTokensDetails: r.Usage.PromptTokensDetails,
+	}
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	return u
+}
+
+// chatResponse 转换为兼容模式的响应结构
+func (r *nativeResponse) chatResponse(model string) *ChatResponse {
+	resp := &ChatResponse{
+		ID:         r.RequestID,
+		Object:     "chat.completion",
+		Created:    time.Now().Unix(),
+		Model:      model,
+		Choices:    make([]Choice, len(r.Output.Choices)),
+		Usage:      *r.usage(),
+		SearchInfo: r.Output.SearchInfo,
+	}
+	for i, choice := range r.Output.Choices {
+		finishReason := choice.FinishReason
+		if finishReason == "null" {
+			finishReason = ""
+		}
+		resp.Choices[i] = Choice{Index: i, Message: choice.Message, FinishReason: finishReason}
+	}
+	return resp
+}
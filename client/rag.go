@@ -0,0 +1,247 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/lvdashuaibi/GPTUtils/vectorstore"
+)
+
+// defaultRAGPrompt 默认的检索增强系统提示词
+const defaultRAGPrompt = "你是一个严谨的AI助手。请仅根据下面提供的参考资料回答用户问题，" +
+	"并在使用资料的句子后用方括号标注来源编号，例如[1]。如果资料中没有答案，请直接说明不知道。"
+
+// RAGOptions 检索增强对话选项
+type RAGOptions struct {
+	EmbeddingModel string             // 向量模型，默认 DefaultEmbeddingModel
+	TopK           int                // 注入提示词的片段数，默认5
+	MinScore       float64            // 最低相似度分数，低于该分数的片段被丢弃
+	Filter         vectorstore.Filter // 检索时的元数据过滤条件
+	SystemPrompt   string             // 系统提示词，参考资料会附加在其后
+
+	// Reranker 不为nil时先检索 RerankCandidates 个候选，再重排取前 TopK 个；
+	// 此时结果的 Score 和 MinScore 均为重排相关性分数
+	Reranker         Reranker
+	RerankCandidates int // 重排前的候选数，默认50
+}
+
+// RAGDocument 待索引的文本片段
+type RAGDocument struct {
+	ID       string
+	Content  string
+	Metadata map[string]string // 其中 "source" 字段会作为来源显示在提示词中
+}
+
+// RAGAnswer 检索增强对话的结果
+type RAGAnswer struct {
+	Content  string               // 模型回答
+	Sources  []vectorstore.Result // 注入提示词的片段，第i个对应标记[i+1]
+	Cited    []vectorstore.Result // 回答中实际引用的片段
+	Response *ChatResponse        // 原始响应
+}
+
+// RAGChat 基于向量存储的检索增强对话
+type RAGChat struct {
+	client *HTTPClient
+	store  *vectorstore.Store
+	opts   RAGOptions
+}
+
+// NewRAGChat 创建检索增强对话
+func NewRAGChat(c *HTTPClient, store *vectorstore.Store, opts RAGOptions) *RAGChat {
+	if opts.EmbeddingModel == "" {
+		opts.EmbeddingModel = DefaultEmbeddingModel
+	}
+	if opts.TopK <= 0 {
+		opts.TopK = 5
+	}
+	if opts.SystemPrompt == "" {
+		opts.SystemPrompt = defaultRAGPrompt
+	}
+	if opts.RerankCandidates <= 0 {
+		opts.RerankCandidates = 50
+	}
+
+	return &RAGChat{
+		client: c,
+		store:  store,
+		opts:   opts,
+	}
+}
+
+// Store 返回底层向量存储
+func (r *RAGChat) Store() *vectorstore.Store {
+	return r.store
+}
+
+// AddDocuments 计算片段向量并写入向量存储
+func (r *RAGChat) AddDocuments(ctx context.Context, docs []RAGDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	inputs := make([]string, len(docs))
+	for i, doc := range docs {
+		inputs[i] = doc.Content
+	}
+
+	resp, err := r.client.Embeddings(ctx, EmbeddingRequest{
+		Model: r.opts.EmbeddingModel,
+		Input: inputs,
+	})
+	if err != nil {
+		return err
+	}
+	if len(resp.Data) != len(docs) {
+		return fmt.Errorf("embedding count mismatch: got %d, want %d", len(resp.Data), len(docs))
+	}
+
+	records := make([]vectorstore.Record, len(docs))
+	for i, doc := range docs {
+		records[i] = vectorstore.Record{
+			ID:       doc.ID,
+			Vector:   resp.Data[i].Embedding,
+			Content:  doc.Content,
+			Metadata: doc.Metadata,
+		}
+	}
+
+	return r.store.Upsert(records...)
+}
+
+// Retrieve 检索与查询最相关的片段
+func (r *RAGChat) Retrieve(ctx context.Context, query string) ([]vectorstore.Result, error) {
+	resp, err := r.client.Embeddings(ctx, EmbeddingRequest{
+		Model: r.opts.EmbeddingModel,
+		Input: []string{query},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("empty embedding response")
+	}
+
+	k := r.opts.TopK
+	if r.opts.Reranker != nil && r.opts.RerankCandidates > k {
+		k = r.opts.RerankCandidates
+	}
+
+	results, err := r.store.Search(resp.Data[0].Embedding, k, r.opts.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.opts.Reranker != nil && len(results) > 0 {
+		results, err = r.rerank(ctx, query, results)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	kept := results[:0]
+	for _, res := range results {
+		if res.Score >= r.opts.MinScore {
+			kept = append(kept, res)
+		}
+	}
+	return kept, nil
+}
+
+// rerank 对候选片段重排并截取前 TopK 个
+func (r *RAGChat) rerank(ctx context.Context, query string, candidates []vectorstore.Result) ([]vectorstore.Result, error) {
+	documents := make([]string, len(candidates))
+	for i, c := range candidates {
+		documents[i] = c.Content
+	}
+
+	ranked, err := r.opts.Reranker.Rerank(ctx, query, documents, r.opts.TopK)
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]vectorstore.Result, 0, len(ranked))
+	for _, rr := range ranked {
+		if rr.Index < 0 || rr.Index >= len(candidates) {
+			continue
+		}
+		res := candidates[rr.Index]
+		res.Score = rr.RelevanceScore
+		results = append(results, res)
+	}
+	if len(results) > r.opts.TopK {
+		results = results[:r.opts.TopK]
+	}
+	return results, nil
+}
+
+// Ask 检索相关片段、注入系统提示词并回答问题
+// history 为之前的对话（不含系统消息），可以为nil
+func (r *RAGChat) Ask(ctx context.Context, question string, history []Message) (*RAGAnswer, error) {
+	sources, err := r.Retrieve(ctx, question)
+	if err != nil {
+		return nil, err
+	}
+
+	messages := make([]Message, 0, len(history)+2)
+	messages = append(messages, Message{Role: "system", Content: r.systemPrompt(sources)})
+	messages = append(messages, history...)
+	messages = append(messages, Message{Role: "user", Content: question})
+
+	resp, err := r.client.Chat(ctx, ChatRequest{Messages: messages})
+	if err != nil {
+		return nil, err
+	}
+
+	answer := &RAGAnswer{
+		Sources:  sources,
+		Response: resp,
+	}
+	if len(resp.Choices) > 0 {
+		answer.Content = resp.Choices[0].Message.Content
+	}
+	answer.Cited = citedSources(answer.Content, sources)
+
+	return answer, nil
+}
+
+// systemPrompt 将检索到的片段以[编号]标记附加到系统提示词
+func (r *RAGChat) systemPrompt(sources []vectorstore.Result) string {
+	var sb strings.Builder
+	sb.WriteString(r.opts.SystemPrompt)
+	sb.WriteString("\n\n参考资料：\n")
+
+	if len(sources) == 0 {
+		sb.WriteString("（无）\n")
+	}
+	for i, src := range sources {
+		fmt.Fprintf(&sb, "\n[%d]", i+1)
+		if name := src.Metadata["source"]; name != "" {
+			fmt.Fprintf(&sb, " 来源: %s", name)
+		}
+		sb.WriteString("\n")
+		sb.WriteString(strings.TrimSpace(src.Content))
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+var citationPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// citedSources 根据回答中的[编号]标记找出被引用的片段，按首次出现的顺序返回
+func citedSources(answer string, sources []vectorstore.Result) []vectorstore.Result {
+	var cited []vectorstore.Result
+	seen := make(map[int]bool)
+	for _, m := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n < 1 || n > len(sources) || seen[n] {
+			continue
+		}
+		seen[n] = true
+		cited = append(cited, sources[n-1])
+	}
+	return cited
+}
@@ -0,0 +1,337 @@
+package client
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// RateLimit 单个模型的限流配额
+type RateLimit struct {
+	RPM            int // 每分钟请求数，0表示不限制
+	TPM            int // 每分钟估算token数，0表示不限制
+	MaxConcurrency int // 最大并发请求数，0表示不限制
+}
+
+// RateLimiterConfig 限流器配置
+type RateLimiterConfig struct {
+	Default RateLimit            // 未单独配置的模型使用的配额
+	Models  map[string]RateLimit // 按模型名称配置的配额
+
+	// FailFast 为true时配额不足立即返回 ErrRateLimited；
+	// 否则阻塞等待，仅当等待会超过 context 截止时间时才返回错误
+	FailFast bool
+
+	// MaxRetries 收到429后的最大重试次数，0使用默认值3，负数表示不重试。
+	// Client 基于 openai-go，SDK 自身也会重试429（默认2次），两者叠加时
+	// 单次调用最多发送 (MaxRetries+1)*(SDK重试次数+1) 个请求，可按需设为负数只保留SDK重试
+	MaxRetries  int
+	BaseBackoff time.Duration // 429退避基础时长，默认1秒
+	MaxBackoff  time.Duration // 429退避最大时长，默认1分钟
+
+	// TokenEstimator 估算文本的token数，默认按字符粗略估算
+	TokenEstimator func(text string) int
+}
+
+// RateLimiter 按模型区分的客户端限流器
+// 同时限制请求数(RPM)、估算token数(TPM)与并发数，并在收到429时自适应退避
+type RateLimiter struct {
+	config RateLimiterConfig
+
+	mu     sync.Mutex
+	models map[string]*modelLimiter
+}
+
+// modelLimiter 单个模型的限流状态
+type modelLimiter struct {
+	requests *tokenBucket
+	tokens   *tokenBucket
+	sem      chan struct{}
+
+	backoffUntil time.Time
+	failures     int
+}
+
+// NewRateLimiter 创建限流器
+func NewRateLimiter(cfg RateLimiterConfig) *RateLimiter {
+	switch {
+	case cfg.MaxRetries == 0:
+		cfg.MaxRetries = 3
+	case cfg.MaxRetries < 0:
+		cfg.MaxRetries = 0
+	}
+	if cfg.BaseBackoff <= 0 {
+		cfg.BaseBackoff = time.Second
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = time.Minute
+	}
+	if cfg.TokenEstimator == nil {
+		cfg.TokenEstimator = EstimateTokens
+	}
+
+	return &RateLimiter{
+		config: cfg,
+		models: make(map[string]*modelLimiter),
+	}
+}
+
+// limiter 获取（必要时创建）模型对应的限流状态
+func (l *RateLimiter) limiter(model string) *modelLimiter {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if m, ok := l.models[model]; ok {
+		return m
+	}
+
+	limit, ok := l.config.Models[model]
+	if !ok {
+		limit = l.config.Default
+	}
+
+	m := &modelLimiter{
+		requests: newTokenBucket(limit.RPM),
+		tokens:   newTokenBucket(limit.TPM),
+	}
+	if limit.MaxConcurrency > 0 {
+		m.sem = make(chan struct{}, limit.MaxConcurrency)
+	}
+	l.models[model] = m
+	return m
+}
+
+// Acquire 为一次请求获取配额
+// tokens 为估算的token数；返回的 release 必须在请求结束后调用，
+// 传入实际消耗的token数（未知时传0）用于校正TPM配额
+func (l *RateLimiter) Acquire(ctx context.Context, model string, tokens int) (release func(actualTokens int), err error) {
+	m := l.limiter(model)
+
+	if m.sem != nil {
+		if l.config.FailFast {
+			select {
+			case m.sem <- struct{}{}:
+			default:
+				return nil, ErrRateLimited
+			}
+		} else {
+			select {
+			case m.sem <- struct{}{}:
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+	}
+
+	releaseSem := func() {
+		if m.sem != nil {
+			<-m.sem
+		}
+	}
+
+	wait, ok := l.reserve(ctx, m, tokens)
+	if !ok {
+		releaseSem()
+		return nil, ErrRateLimited
+	}
+
+	if wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			l.mu.Lock()
+			m.requests.refund(1)
+			m.tokens.refund(float64(tokens))
+			l.mu.Unlock()
+			releaseSem()
+			return nil, ctx.Err()
+		}
+	}
+
+	var once sync.Once
+	return func(actualTokens int) {
+		once.Do(func() {
+			if actualTokens > 0 {
+				l.mu.Lock()
+				m.tokens.refund(float64(tokens - actualTokens))
+				l.mu.Unlock()
+			}
+			releaseSem()
+		})
+	}, nil
+}
+
+// reserve 预占配额并返回需要等待的时长
+// 当配置为快速失败或等待会超过截止时间时，不预占并返回false
+func (l *RateLimiter) reserve(ctx context.Context, m *modelLimiter, tokens int) (time.Duration, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	wait := m.requests.waitFor(now, 1)
+	if w := m.tokens.waitFor(now, float64(tokens)); w > wait {
+		wait = w
+	}
+	if w := m.backoffUntil.Sub(now); w > wait {
+		wait = w
+	}
+
+	if wait > 0 {
+		if l.config.FailFast {
+			return 0, false
+		}
+		if deadline, ok := ctx.Deadline(); ok && now.Add(wait).After(deadline) {
+			return 0, false
+		}
+	}
+
+	m.requests.take(now, 1)
+	m.tokens.take(now, float64(tokens))
+	return wait, true
+}
+
+// Penalize 记录一次429，按指数退避暂停该模型的后续请求
+// retryAfter 为服务端建议的等待时长，大于计算值时优先使用
+func (l *RateLimiter) Penalize(model string, retryAfter time.Duration) time.Duration {
+	m := l.limiter(model)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	m.failures++
+	backoff := float64(l.config.BaseBackoff) * math.Pow(2, float64(m.failures-1))
+	if backoff > float64(l.config.MaxBackoff) {
+		backoff = float64(l.config.MaxBackoff)
+	}
+	// 加入抖动，避免并发请求同时恢复
+	wait := time.Duration(backoff/2 + rand.Float64()*backoff/2)
+	if retryAfter > wait {
+		wait = retryAfter
+	}
+
+	if until := time.Now().Add(wait); until.After(m.backoffUntil) {
+		m.backoffUntil = until
+	}
+	return wait
+}
+
+// reset 请求成功后清除退避状态
+func (l *RateLimiter) reset(model string) {
+	m := l.limiter(model)
+
+	l.mu.Lock()
+	m.failures = 0
+	l.mu.Unlock()
+}
+
+// run 在限流保护下执行一次请求，遇到429时退避重试
+// call 返回实际的token使用情况（未知时返回nil）
+func (l *RateLimiter) run(ctx context.Context, model, text string, call func() (*Usage, error)) error {
+	tokens := l.config.TokenEstimator(text)
+
+	for attempt := 0; ; attempt++ {
+		release, err := l.Acquire(ctx, model, tokens)
+		if err != nil {
+			return err
+		}
+
+		usage, err := call()
+		actual := 0
+		if usage != nil {
+			actual = usage.TotalTokens
+		}
+		release(actual)
+
+		if err == nil {
+			l.reset(model)
+			return nil
+		}
+
+		limited, retryAfter := isTooManyRequests(err)
+		if !limited {
+			return err
+		}
+		l.Penalize(model, retryAfter)
+		if attempt >= l.config.MaxRetries {
+			return err
+		}
+	}
+}
+
+// EstimateTokens 粗略估算文本的token数
+// 中日韩字符约1个token，其他字符约4个为1个token
+func EstimateTokens(text string) int {
+	cjk, other := 0, 0
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+			unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r) {
+			cjk++
+		} else {
+			other++
+		}
+	}
+	return cjk + (other+3)/4
+}
+
+// tokenBucket 令牌桶，容量为每分钟配额，允许透支以实现预占
+type tokenBucket struct {
+	capacity float64
+	rate     float64 // 每秒补充量
+	tokens   float64
+	last     time.Time
+}
+
+// newTokenBucket 创建每分钟 perMinute 的令牌桶，perMinute<=0 时返回nil表示不限制
+func newTokenBucket(perMinute int) *tokenBucket {
+	if perMinute <= 0 {
+		return nil
+	}
+	return &tokenBucket{
+		capacity: float64(perMinute),
+		rate:     float64(perMinute) / 60,
+		tokens:   float64(perMinute),
+		last:     time.Now(),
+	}
+}
+
+func (b *tokenBucket) refill(now time.Time) {
+	elapsed := now.Sub(b.last).Seconds()
+	if elapsed > 0 {
+		b.tokens = math.Min(b.capacity, b.tokens+elapsed*b.rate)
+		b.last = now
+	}
+}
+
+// waitFor 返回获得n个令牌需要等待的时长
+func (b *tokenBucket) waitFor(now time.Time, n float64) time.Duration {
+	if b == nil {
+		return 0
+	}
+	b.refill(now)
+	// 单次请求超过桶容量时按容量计算，避免永远无法满足
+	n = math.Min(n, b.capacity)
+	if b.tokens >= n {
+		return 0
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
+func (b *tokenBucket) take(now time.Time, n float64) {
+	if b == nil {
+		return
+	}
+	b.refill(now)
+	b.tokens -= math.Min(n, b.capacity)
+}
+
+func (b *tokenBucket) refund(n float64) {
+	if b == nil {
+		return
+	}
+	b.tokens = math.Min(b.capacity, b.tokens+n)
+}
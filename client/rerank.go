@@ -0,0 +1,148 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+)
+
+// DefaultRerankModel 默认的重排模型
+const DefaultRerankModel = "gte-rerank"
+
+// RerankRequest 文本重排请求
+type RerankRequest struct {
+	Model           string   // 重排模型，默认 DefaultRerankModel
+	Query           string   // 查询
+	Documents       []string // 候选文本
+	TopN            int      // 返回的结果数，0表示返回全部
+	ReturnDocuments bool     // 是否在结果中返回原文
+}
+
+// RerankResult 重排结果
+type RerankResult struct {
+	Index          int     `json:"index"`           // 在输入 Documents 中的位置
+	RelevanceScore float64 `json:"relevance_score"` // 相关性分数，越大越相关
+	Document       string  `json:"-"`               // 原文
+}
+
+// RerankResponse 重排响应，Results 按相关性降序排列
+type RerankResponse struct {
+	RequestID string
+	Results   []RerankResult
+	Usage     struct {
+		TotalTokens int `json:"total_tokens"`
+	}
+}
+
+// Reranker 文本重排接口，*HTTPClient 实现了该接口
+type Reranker interface {
+	Rerank(ctx context.Context, query string, documents []string, topN int) ([]RerankResult, error)
+}
+
+// Rerank 对候选文本按与查询的相关性重新排序，结果包含原文
+func (c *HTTPClient) Rerank(ctx context.Context, query string, documents []string, topN int) ([]RerankResult, error) {
+	resp, err := c.RerankWithOptions(ctx, RerankRequest{
+		Query:     query,
+		Documents: documents,
+		TopN:      topN,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Results, nil
+}
+
+// RerankWithOptions 发送重排请求（DashScope 原生接口）
+func (c *HTTPClient) RerankWithOptions(ctx context.Context, req RerankRequest) (*RerankResponse, error) {
+	if err := c.config.CheckDashScope(); err != nil {
+		return nil, err
+	}
+	if req.Model == "" {
+		req.Model = DefaultRerankModel
+	}
+	if len(req.Documents) == 0 {
+		return nil, errors.New("rerank documents are empty")
+	}
+
+	payload := map[string]interface{}{
+		"model": req.Model,
+		"input": map[string]interface{}{
+			"query":     req.Query,
+			"documents": req.Documents,
+		},
+		"parameters": map[string]interface{}{
+			"return_documents": req.ReturnDocuments,
+		},
+	}
+	if req.TopN > 0 {
+		payload["parameters"].(map[string]interface{})["top_n"] = req.TopN
+	}
+
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST",
+		c.config.DashScopeBaseURL()+"/services/rerank/text-rerank/text-rerank",
+		bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	c.config.SetHeaders(httpReq.Header)
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw struct {
+		RequestID string `json:"request_id"`
+		Output    struct {
+			Results []struct {
+				RerankResult
+				Document struct {
+					Text string `json:"text"`
+				} `json:"document"`
+			} `json:"results"`
+		} `json:"output"`
+		Usage struct {
+			TotalTokens int `json:"total_tokens"`
+		} `json:"usage"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+
+	rerankResp := &RerankResponse{
+		RequestID: raw.RequestID,
+		Results:   make([]RerankResult, len(raw.Output.Results)),
+	}
+	rerankResp.Usage.TotalTokens = raw.Usage.TotalTokens
+	for i, r := range raw.Output.Results {
+		result := r.RerankResult
+		result.Document = r.Document.Text
+		// 未要求返回原文时从输入中补齐
+		if result.Document == "" && result.Index >= 0 && result.Index < len(req.Documents) {
+			result.Document = req.Documents[result.Index]
+		}
+		rerankResp.Results[i] = result
+	}
+
+	return rerankResp, nil
+}
@@ -22,15 +22,13 @@ func (c *Client) ChatWithSearch(ctx context.Context, message string, searchOpts
 		EnableSearch: &searchOpts.EnableSearch,
 	}
 
-	params := openai.ChatCompletionNewParams{
-		Messages: openai.F(opts.Messages),
-		Model:    openai.F(c.config.Model),
-	}
+	opts.Model = c.config.Model
 
-	// 注意: 联网搜索功能需要通过 DashScope 原生 API 实现
-	// OpenAI 兼容模式可能不支持此功能
+	// 兼容模式只能开关联网搜索，强制搜索、搜索策略、返回搜索来源等
+	// 需要通过 NativeClient 与 DashScopeParams.SearchOptions 使用原生协议
+	params, reqOpts := newParams(opts, c.config.ProviderQuirks())
 
-	return c.client.Chat.Completions.New(ctx, params)
+	return c.client.Chat.Completions.New(ctx, params, reqOpts...)
 }
 
 // ChatWithSearchStream 带联网搜索的流式聊天
@@ -42,20 +40,17 @@ func (c *Client) ChatWithSearchStream(ctx context.Context, message string, searc
 		EnableSearch: &searchOpts.EnableSearch,
 	}
 
-	params := openai.ChatCompletionNewParams{
-		Messages: openai.F(opts.Messages),
-		Model:    openai.F(c.config.Model),
-	}
+	opts.Model = c.config.Model
 
-	// 注意: 联网搜索功能需要通过 DashScope 原生 API 实现
-	// OpenAI 兼容模式可能不支持此功能
+	// 搜索策略等原生参数见 NativeClient
+	params, reqOpts := newParams(opts, c.config.ProviderQuirks())
 
 	// 设置流式输出选项
 	params.StreamOptions = openai.F(openai.ChatCompletionStreamOptionsParam{
 		IncludeUsage: openai.F(true),
 	})
 
-	stream := c.client.Chat.Completions.NewStreaming(ctx, params)
+	stream := c.client.Chat.Completions.NewStreaming(ctx, params, reqOpts...)
 
 	// 处理流式响应
 	for stream.Next() {
@@ -0,0 +1,298 @@
+package client
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+)
+
+// DefaultSpeechModel 默认的语音合成模型
+const DefaultSpeechModel = "cosyvoice-v1"
+
+// 常用音频格式
+const (
+	AudioMP3 = "mp3"
+	AudioWAV = "wav"
+	AudioPCM = "pcm"
+)
+
+// SpeechRequest 语音合成请求
+type SpeechRequest struct {
+	Model      string  // 模型，默认 DefaultSpeechModel；Sambert 的音色包含在模型名中，如 sambert-zhichu-v1
+	Text       string  // 待合成的文本
+	Voice      string  // 音色，CosyVoice 必填，如 longxiaochun
+	Format     string  // 音频格式，默认 mp3
+	SampleRate int     // 采样率，默认22050（Sambert 默认16000）
+	Volume     int     // 音量 0-100，默认50
+	Rate       float64 // 语速 0.5-2，默认1
+	Pitch      float64 // 语调 0.5-2，默认1
+}
+
+// Synthesize 合成语音，返回边合成边读取的音频流
+// voice 以 sambert- 开头时视为 Sambert 模型名，否则为 CosyVoice 音色；format 为空时使用 mp3
+func (c *HTTPClient) Synthesize(ctx context.Context, text, voice, format string) (io.ReadCloser, error) {
+	req := SpeechRequest{Text: text, Voice: voice, Format: format}
+	if strings.HasPrefix(voice, "sambert-") {
+		req.Model, req.Voice = voice, ""
+	}
+	return c.SynthesizeWithOptions(ctx, req)
+}
+
+// SynthesizeWithOptions 通过 DashScope WebSocket 接口合成语音
+// 任务开始后即返回，音频在读取时持续到达；合成失败时 Read 返回 *TaskError。
+// 调用方读完或放弃读取后必须关闭返回的 ReadCloser
+func (c *HTTPClient) SynthesizeWithOptions(ctx context.Context, req SpeechRequest) (io.ReadCloser, error) {
+	if req.Text == "" {
+		return nil, errors.New("speech text is empty")
+	}
+	if req.Model == "" {
+		req.Model = DefaultSpeechModel
+	}
+	if req.Format == "" {
+		req.Format = AudioMP3
+	}
+	sambert := strings.HasPrefix(req.Model, "sambert")
+	if !sambert && req.Voice == "" {
+		return nil, fmt.Errorf("voice is required for model %s", req.Model)
+	}
+
+	conn, err := c.dialWebSocket(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	taskID := newTaskID()
+	run := wsMessage{
+		Header: wsHeader{Action: "run-task", TaskID: taskID, Streaming: "duplex"},
+		Payload: &wsPayload{
+			TaskGroup:  "audio",
+			Task:       "tts",
+			Function:   "SpeechSynthesizer",
+			Model:      req.Model,
+			Parameters: speechParameters(req, sambert),
+			Input:      map[string]interface{}{},
+		},
+	}
+	if sambert {
+		// Sambert 不支持双工，文本随 run-task 一次发送
+		run.Header.Streaming = "out"
+		run.Payload.Input["text"] = req.Text
+	}
+	if err := conn.WriteJSON(run); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	err = waitTaskStarted(conn, taskID)
+	stop()
+	if err != nil {
+		conn.Close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	if !sambert {
+		for _, msg := range []wsMessage{
+			{
+				Header:  wsHeader{Action: "continue-task", TaskID: taskID, Streaming: "duplex"},
+				Payload: &wsPayload{Input: map[string]interface{}{"text": req.Text}},
+			},
+			{
+				Header:  wsHeader{Action: "finish-task", TaskID: taskID, Streaming: "duplex"},
+				Payload: &wsPayload{Input: map[string]interface{}{}},
+			},
+		} {
+			if err := conn.WriteJSON(msg); err != nil {
+				conn.Close()
+				return nil, err
+			}
+		}
+	}
+
+	pr, pw := io.Pipe()
+	stream := &audioStream{pr: pr, conn: conn}
+	go stream.receive(ctx, pw, taskID)
+	return stream, nil
+}
+
+// speechParameters 构造语音合成参数
+func speechParameters(req SpeechRequest, sambert bool) map[string]interface{} {
+	params := map[string]interface{}{
+		"text_type": "PlainText",
+		"format":    req.Format,
+	}
+	if req.Voice != "" {
+		params["voice"] = req.Voice
+	}
+	switch {
+	case req.SampleRate > 0:
+		params["sample_rate"] = req.SampleRate
+	case sambert:
+		params["sample_rate"] = 16000
+	default:
+		params["sample_rate"] = 22050
+	}
+	if req.Volume > 0 {
+		params["volume"] = req.Volume
+	}
+	if req.Rate > 0 {
+		params["rate"] = req.Rate
+	}
+	if req.Pitch > 0 {
+		params["pitch"] = req.Pitch
+	}
+	return params
+}
+
+// wsHeader WebSocket 指令与事件的头部
+type wsHeader struct {
+	Action       string `json:"action,omitempty"`
+	Event        string `json:"event,omitempty"`
+	TaskID       string `json:"task_id"`
+	Streaming    string `json:"streaming,omitempty"`
+	ErrorCode    string `json:"error_code,omitempty"`
+	ErrorMessage string `json:"error_message,omitempty"`
+}
+
+// wsPayload WebSocket 指令的负载
+type wsPayload struct {
+	TaskGroup  string                 `json:"task_group,omitempty"`
+	Task       string                 `json:"task,omitempty"`
+	Function   string                 `json:"function,omitempty"`
+	Model      string                 `json:"model,omitempty"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	Input      map[string]interface{} `json:"input"`
+}
+
+// wsMessage WebSocket 指令或事件
+type wsMessage struct {
+	Header  wsHeader   `json:"header"`
+	Payload *wsPayload `json:"payload,omitempty"`
+}
+
+// taskError 将 task-failed 事件转换为错误
+func (h wsHeader) taskError() error {
+	return &TaskError{TaskID: h.TaskID, Status: TaskFailed, Code: h.ErrorCode, Message: h.ErrorMessage}
+}
+
+// dialWebSocket 连接 DashScope WebSocket 接口，握手失败时返回 *APIError
+func (c *HTTPClient) dialWebSocket(ctx context.Context) (*websocket.Conn, error) {
+	if err := c.config.CheckDashScope(); err != nil {
+		return nil, err
+	}
+	header := http.Header{}
+	c.config.SetHeaders(header)
+
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, c.config.DashScopeWebSocketURL(), header)
+	if err != nil {
+		if resp != nil {
+			defer resp.Body.Close()
+			return nil, newAPIError(resp)
+		}
+		return nil, err
+	}
+	return conn, nil
+}
+
+// waitTaskStarted 等待 task-started 事件
+func waitTaskStarted(conn *websocket.Conn, taskID string) error {
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return err
+		}
+		if msg.Header.TaskID != "" && msg.Header.TaskID != taskID {
+			continue
+		}
+		switch msg.Header.Event {
+		case "task-started":
+			return nil
+		case "task-failed":
+			return msg.Header.taskError()
+		}
+	}
+}
+
+// newTaskID 生成32位十六进制的任务ID
+func newTaskID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// audioStream 边接收边读取的合成音频
+type audioStream struct {
+	pr   *io.PipeReader
+	conn *websocket.Conn
+
+	closeOnce sync.Once
+}
+
+// receive 将二进制帧写入管道，直到任务结束、失败或 ctx 取消
+func (s *audioStream) receive(ctx context.Context, pw *io.PipeWriter, taskID string) {
+	stop := context.AfterFunc(ctx, func() {
+		pw.CloseWithError(ctx.Err())
+		s.conn.Close()
+	})
+	defer stop()
+
+	for {
+		typ, data, err := s.conn.ReadMessage()
+		if err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+
+		if typ == websocket.BinaryMessage {
+			if _, err := pw.Write(data); err != nil {
+				// 读取端已关闭
+				return
+			}
+			continue
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+		if msg.Header.TaskID != "" && msg.Header.TaskID != taskID {
+			continue
+		}
+		switch msg.Header.Event {
+		case "task-finished":
+			pw.Close()
+			return
+		case "task-failed":
+			pw.CloseWithError(msg.Header.taskError())
+			return
+		}
+	}
+}
+
+// Read 实现 io.Reader 接口
+func (s *audioStream) Read(p []byte) (int, error) {
+	return s.pr.Read(p)
+}
+
+// Close 关闭连接，未读完的音频被丢弃
+func (s *audioStream) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		s.pr.Close()
+		err = s.conn.Close()
+	})
+	return err
+}
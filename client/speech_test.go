@@ -0,0 +1,265 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/lvdashuaibi/GPTUtils/config"
+)
+
+// speechFixture 录制的一次语音合成会话，见 testdata/speech
+// started 在收到 run-task 后发送，actions 为之后客户端应发送的指令，events 在收到全部指令后发送
+type speechFixture struct {
+	Request struct {
+		Model      string `json:"model"`
+		Streaming  string `json:"streaming"`
+		Voice      string `json:"voice"`
+		Format     string `json:"format"`
+		SampleRate int    `json:"sample_rate"`
+		Text       string `json:"text"`
+	} `json:"request"`
+	Started []wsFrame `json:"started"`
+	Actions []string  `json:"actions"`
+	Events  []wsFrame `json:"events"`
+}
+
+// wsFrame 录制的服务端帧，text 为JSON事件，binary 为 base64 编码的音频
+type wsFrame struct {
+	Text   json.RawMessage `json:"text,omitempty"`
+	Binary string          `json:"binary,omitempty"`
+}
+
+func loadSpeechFixture(t *testing.T, name string) *speechFixture {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "speech", name+".json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f speechFixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		t.Fatal(err)
+	}
+	return &f
+}
+
+// audio 返回 events 中全部音频帧拼接后的内容
+func (f *speechFixture) audio(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	for _, frame := range f.Events {
+		if frame.Binary == "" {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(frame.Binary)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf.Write(data)
+	}
+	return buf.Bytes()
+}
+
+// newSpeechServer 启动按录制数据回放的 WebSocket 服务，校验客户端发送的指令
+func newSpeechServer(t *testing.T, f *speechFixture) *HTTPClient {
+	t.Helper()
+	upgrader := websocket.Upgrader{}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api-ws/v1/inference", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q", got)
+		}
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Errorf("upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		var run wsMessage
+		if err := conn.ReadJSON(&run); err != nil {
+			t.Errorf("read run-task: %v", err)
+			return
+		}
+		checkRunTask(t, f, run)
+		taskID := run.Header.TaskID
+
+		send := func(frames []wsFrame) bool {
+			for _, frame := range frames {
+				var err error
+				if frame.Binary != "" {
+					data, _ := base64.StdEncoding.DecodeString(frame.Binary)
+					err = conn.WriteMessage(websocket.BinaryMessage, data)
+				} else {
+					text := strings.ReplaceAll(string(frame.Text), "{{task_id}}", taskID)
+					err = conn.WriteMessage(websocket.TextMessage, []byte(text))
+				}
+				if err != nil {
+					t.Errorf("write frame: %v", err)
+					return false
+				}
+			}
+			return true
+		}
+
+		if !send(f.Started) {
+			return
+		}
+		for _, action := range f.Actions {
+			var msg wsMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				t.Errorf("read %s: %v", action, err)
+				return
+			}
+			if msg.Header.Action != action || msg.Header.TaskID != taskID {
+				t.Errorf("got %s for task %s, want %s for task %s", msg.Header.Action, msg.Header.TaskID, action, taskID)
+			}
+			if action == "continue-task" && (msg.Payload == nil || msg.Payload.Input["text"] != f.Request.Text) {
+				t.Errorf("continue-task payload = %+v, want text %q", msg.Payload, f.Request.Text)
+			}
+		}
+		send(f.Events)
+
+		// 等待客户端关闭连接
+		conn.ReadMessage()
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return NewHTTPClient(&config.Config{APIKey: "test-key", BaseURL: srv.URL, NativeBaseURL: srv.URL + "/api/v1"})
+}
+
+// checkRunTask 校验 run-task 指令与录制的请求一致
+func checkRunTask(t *testing.T, f *speechFixture, run wsMessage) {
+	t.Helper()
+	if run.Header.Action != "run-task" || run.Header.TaskID == "" {
+		t.Errorf("header = %+v", run.Header)
+	}
+	if run.Header.Streaming != f.Request.Streaming {
+		t.Errorf("streaming = %q, want %q", run.Header.Streaming, f.Request.Streaming)
+	}
+	if run.Payload == nil {
+		t.Error("run-task without payload")
+		return
+	}
+	p := run.Payload
+	if p.Model != f.Request.Model || p.TaskGroup != "audio" || p.Task != "tts" || p.Function != "SpeechSynthesizer" {
+		t.Errorf("payload = %+v", p)
+	}
+	if got, _ := p.Parameters["voice"].(string); got != f.Request.Voice {
+		t.Errorf("voice = %q, want %q", got, f.Request.Voice)
+	}
+	if got, _ := p.Parameters["format"].(string); got != f.Request.Format {
+		t.Errorf("format = %q, want %q", got, f.Request.Format)
+	}
+	if got, _ := p.Parameters["sample_rate"].(float64); int(got) != f.Request.SampleRate {
+		t.Errorf("sample_rate = %v, want %d", got, f.Request.SampleRate)
+	}
+	// 双工模式的文本通过 continue-task 发送
+	text, _ := p.Input["text"].(string)
+	if f.Request.Streaming == "duplex" && text != "" {
+		t.Errorf("duplex run-task carries text %q", text)
+	}
+	if f.Request.Streaming == "out" && text != f.Request.Text {
+		t.Errorf("run-task text = %q, want %q", text, f.Request.Text)
+	}
+}
+
+func TestSynthesizeCosyVoice(t *testing.T) {
+	f := loadSpeechFixture(t, "cosyvoice")
+	c := newSpeechServer(t, f)
+
+	audio, err := c.Synthesize(context.Background(), f.Request.Text, f.Request.Voice, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audio.Close()
+
+	data, err := io.ReadAll(audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := f.audio(t); !bytes.Equal(data, want) {
+		t.Errorf("audio = %x, want %x", data, want)
+	}
+}
+
+func TestSynthesizeSambert(t *testing.T) {
+	f := loadSpeechFixture(t, "sambert")
+	c := newSpeechServer(t, f)
+
+	audio, err := c.Synthesize(context.Background(), f.Request.Text, f.Request.Model, AudioWAV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audio.Close()
+
+	data, err := io.ReadAll(audio)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := f.audio(t); !bytes.Equal(data, want) {
+		t.Errorf("audio = %x, want %x", data, want)
+	}
+}
+
+func TestSynthesizeTaskFailed(t *testing.T) {
+	f := loadSpeechFixture(t, "cosyvoice_failed")
+	c := newSpeechServer(t, f)
+
+	audio, err := c.Synthesize(context.Background(), f.Request.Text, f.Request.Voice, AudioMP3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer audio.Close()
+
+	// 失败前收到的音频仍然可读，之后 Read 返回 *TaskError
+	data, err := io.ReadAll(audio)
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) {
+		t.Fatalf("err = %v, want *TaskError", err)
+	}
+	if taskErr.Status != TaskFailed || taskErr.Code != "InternalError" || taskErr.TaskID == "" {
+		t.Errorf("task error = %+v", taskErr)
+	}
+	if want := f.audio(t); !bytes.Equal(data, want) {
+		t.Errorf("audio before failure = %x, want %x", data, want)
+	}
+}
+
+func TestSynthesizeRejected(t *testing.T) {
+	f := loadSpeechFixture(t, "cosyvoice_rejected")
+	c := newSpeechServer(t, f)
+
+	// 任务未开始即失败时直接返回错误
+	_, err := c.Synthesize(context.Background(), f.Request.Text, f.Request.Voice, "")
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) || taskErr.Code != "InvalidParameter" {
+		t.Fatalf("err = %v, want InvalidParameter *TaskError", err)
+	}
+}
+
+func TestSynthesizeHandshakeError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"code":"InvalidApiKey","message":"Invalid API-key provided."}`, http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	c := NewHTTPClient(&config.Config{APIKey: "bad-key", BaseURL: srv.URL, NativeBaseURL: srv.URL + "/api/v1"})
+
+	_, err := c.Synthesize(context.Background(), "你好", "longxiaochun", "")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want 401 *APIError", err)
+	}
+}
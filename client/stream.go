@@ -5,75 +5,78 @@ import (
 	"io"
 
 	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
 )
 
 // ChatStream 流式聊天
 func (c *Client) ChatStream(ctx context.Context, opts ChatOptions, handler StreamHandler) error {
+	_, err := c.ChatStreamWithUsage(ctx, opts, handler)
+	return err
+}
+
+// ChatStreamWithUsage 流式聊天，返回服务端报告的token使用情况（可能为nil）
+func (c *Client) ChatStreamWithUsage(ctx context.Context, opts ChatOptions, handler StreamHandler) (*Usage, error) {
 	if opts.Model == "" {
 		opts.Model = c.config.Model
 	}
-
-	params := openai.ChatCompletionNewParams{
-		Messages: openai.F(opts.Messages),
-		Model:    openai.F(opts.Model),
+	opts.Model = c.config.ResolveModel(opts.Model)
+	if err := c.validate(opts); err != nil {
+		return nil, err
 	}
 
-	// 设置可选参数
-	if opts.Temperature != nil {
-		params.Temperature = openai.F(*opts.Temperature)
-	}
-	if opts.TopP != nil {
-		params.TopP = openai.F(*opts.TopP)
-	}
-	if opts.MaxTokens != nil {
-		params.MaxTokens = openai.F(*opts.MaxTokens)
-	}
-	if opts.PresencePenalty != nil {
-		params.PresencePenalty = openai.F(*opts.PresencePenalty)
-	}
-	if len(opts.Tools) > 0 {
-		params.Tools = openai.F(opts.Tools)
-	}
-	if opts.ToolChoice != nil {
-		params.ToolChoice = openai.F(opts.ToolChoice)
-	}
-	if opts.ParallelToolCalls != nil {
-		params.ParallelToolCalls = openai.F(*opts.ParallelToolCalls)
-	}
-	if opts.Seed != nil {
-		params.Seed = openai.F(*opts.Seed)
+	quirks := c.config.ProviderQuirks()
+	params, reqOpts := newParams(opts, quirks)
+
+	// 设置流式输出选项
+	if !quirks.NoStreamUsage {
+		params.StreamOptions = openai.F(openai.ChatCompletionStreamOptionsParam{
+			IncludeUsage: openai.F(true),
+		})
 	}
-	if len(opts.Stop) > 0 {
-		params.Stop = openai.F[openai.ChatCompletionNewParamsStopUnion](
-			openai.ChatCompletionNewParamsStopArray(opts.Stop),
-		)
+
+	if c.limiter == nil {
+		return c.doChatStream(ctx, params, reqOpts, handler)
 	}
 
-	// 设置流式输出选项
-	params.StreamOptions = openai.F(openai.ChatCompletionStreamOptionsParam{
-		IncludeUsage: openai.F(true),
+	var usage *Usage
+	err := c.limiter.run(ctx, opts.Model, optionsText(opts), func() (*Usage, error) {
+		var err error
+		usage, err = c.doChatStream(ctx, params, reqOpts, handler)
+		return usage, err
 	})
+	return usage, err
+}
 
-	stream := c.client.Chat.Completions.NewStreaming(ctx, params)
+// doChatStream 执行一次流式请求，返回最后一个块携带的token使用情况
+func (c *Client) doChatStream(ctx context.Context, params openai.ChatCompletionNewParams, reqOpts []option.RequestOption, handler StreamHandler) (*Usage, error) {
+	stream := c.client.Chat.Completions.NewStreaming(ctx, params, reqOpts...)
 
+	var usage *Usage
 	// 处理流式响应
 	for stream.Next() {
 		chunk := stream.Current()
+		if chunk.Usage.TotalTokens > 0 {
+			usage = &Usage{
+				PromptTokens:     int(chunk.Usage.PromptTokens),
+				CompletionTokens: int(chunk.Usage.CompletionTokens),
+				TotalTokens:      int(chunk.Usage.TotalTokens),
+			}
+		}
 		if len(chunk.Choices) > 0 {
 			content := chunk.Choices[0].Delta.Content
 			if content != "" {
 				if err := handler(content); err != nil {
-					return err
+					return usage, err
 				}
 			}
 		}
 	}
 
 	if err := stream.Err(); err != nil {
-		return err
+		return usage, err
 	}
 
-	return nil
+	return usage, nil
 }
 
 // SimpleChatStream 简单流式聊天
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// 异步任务状态
+const (
+	TaskPending   = "PENDING"
+	TaskRunning   = "RUNNING"
+	TaskSucceeded = "SUCCEEDED"
+	TaskFailed    = "FAILED"
+	TaskCanceled  = "CANCELED"
+	TaskUnknown   = "UNKNOWN" // 任务不存在或已过期
+)
+
+// Task DashScope 异步任务
+// 图像生成、录音文件识别等耗时较长的接口以 X-DashScope-Async: enable 提交后返回任务，
+// 之后通过 /tasks/{id} 查询状态与结果
+type Task struct {
+	RequestID     string          `json:"request_id"`
+	ID            string          `json:"task_id"`
+	Status        string          `json:"task_status"`
+	SubmitTime    string          `json:"submit_time,omitempty"`
+	ScheduledTime string          `json:"scheduled_time,omitempty"`
+	EndTime       string          `json:"end_time,omitempty"`
+	Code          string          `json:"code,omitempty"`
+	Message       string          `json:"message,omitempty"`
+	Output        json.RawMessage `json:"output,omitempty"` // 完整的 output 对象，由具体接口解析
+	Usage         json.RawMessage `json:"usage,omitempty"`
+}
+
+// Done 任务是否已结束
+func (t *Task) Done() bool {
+	switch t.Status {
+	case TaskSucceeded, TaskFailed, TaskCanceled, TaskUnknown:
+		return true
+	}
+	return false
+}
+
+// Err 任务未成功结束时返回 *TaskError
+func (t *Task) Err() error {
+	if !t.Done() || t.Status == TaskSucceeded {
+		return nil
+	}
+	return &TaskError{TaskID: t.ID, Status: t.Status, Code: t.Code, Message: t.Message}
+}
+
+// TaskError 异步任务失败、被取消或不存在
+type TaskError struct {
+	TaskID  string
+	Status  string
+	Code    string
+	Message string
+}
+
+// Error 实现 error 接口
+func (e *TaskError) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("task %s %s", e.TaskID, e.Status)
+	}
+	return fmt.Sprintf("task %s %s: %s - %s", e.TaskID, e.Status, e.Code, e.Message)
+}
+
+// taskResponse 提交与查询任务的响应
+type taskResponse struct {
+	RequestID string          `json:"request_id"`
+	Output    json.RawMessage `json:"output"`
+	Usage     json.RawMessage `json:"usage"`
+}
+
+// task 从响应中解析任务，output 中的状态字段与结果放在一起
+func (r *taskResponse) task() (*Task, error) {
+	task := &Task{RequestID: r.RequestID, Output: r.Output, Usage: r.Usage}
+	if err := json.Unmarshal(r.Output, task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// SubmitTask 以异步方式调用 DashScope 原生接口，path 为 /api/v1 之后的路径
+func (c *HTTPClient) SubmitTask(ctx context.Context, path string, payload interface{}) (*Task, error) {
+	return c.submitTask(ctx, path, payload, nil)
+}
+
+// submitTask 提交异步任务，header 中的字段附加到请求头
+func (c *HTTPClient) submitTask(ctx context.Context, path string, payload interface{}, header http.Header) (*Task, error) {
+	if err := c.config.CheckDashScope(); err != nil {
+		return nil, err
+	}
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST",
+		c.config.DashScopeBaseURL()+path,
+		bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+
+	c.config.SetHeaders(httpReq.Header)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("X-DashScope-Async", "enable")
+	for k, v := range header {
+		httpReq.Header[k] = v
+	}
+
+	var resp taskResponse
+	if err := c.doRequest(httpReq, &resp); err != nil {
+		return nil, err
+	}
+	return resp.task()
+}
+
+// GetTask 查询任务状态与结果
+func (c *HTTPClient) GetTask(ctx context.Context, id string) (*Task, error) {
+	if err := c.config.CheckDashScope(); err != nil {
+		return nil, err
+	}
+	var resp taskResponse
+	if err := c.doJSON(ctx, "GET", c.config.DashScopeBaseURL()+"/tasks/"+url.PathEscape(id), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.task()
+}
+
+// CancelTask 取消排队中的任务，已开始执行的任务无法取消
+func (c *HTTPClient) CancelTask(ctx context.Context, id string) error {
+	if err := c.config.CheckDashScope(); err != nil {
+		return err
+	}
+	return c.doJSON(ctx, "POST", c.config.DashScopeBaseURL()+"/tasks/"+url.PathEscape(id)+"/cancel", nil, nil)
+}
+
+// TaskPoll 轮询任务的间隔，每次查询后间隔翻倍直到 MaxInterval
+type TaskPoll struct {
+	Interval    time.Duration // 首次查询前的等待时间，默认1秒
+	MaxInterval time.Duration // 最大查询间隔，默认15秒
+	OnPoll      func(*Task)   // 每次查询后调用，可用于显示进度
+}
+
+// WaitTask 轮询任务直到结束或 ctx 取消
+// 任务失败、被取消或不存在时同时返回任务和 *TaskError
+func (c *HTTPClient) WaitTask(ctx context.Context, id string, poll TaskPoll) (*Task, error) {
+	interval := poll.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	maxInterval := poll.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = 15 * time.Second
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		task, err := c.GetTask(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if poll.OnPoll != nil {
+			poll.OnPoll(task)
+		}
+		if task.Done() {
+			return task, task.Err()
+		}
+
+		interval *= 2
+		if interval > maxInterval {
+			interval = maxInterval
+		}
+		timer.Reset(interval)
+	}
+}
+
+// RunTask 提交异步任务并等待其结束
+func (c *HTTPClient) RunTask(ctx context.Context, path string, payload interface{}, poll TaskPoll) (*Task, error) {
+	task, err := c.SubmitTask(ctx, path, payload)
+	if err != nil {
+		return nil, err
+	}
+	if task.Done() {
+		return task, task.Err()
+	}
+	return c.WaitTask(ctx, task.ID, poll)
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultTranscriptionModel 默认的录音文件识别模型
+const DefaultTranscriptionModel = "paraformer-v2"
+
+// transcriptionPath 录音文件识别接口路径
+const transcriptionPath = "/services/audio/asr/transcription"
+
+// TranscriptionRequest 录音文件识别请求
+type TranscriptionRequest struct {
+	Model              string   // 模型，默认 DefaultTranscriptionModel
+	Files              []string // 音频地址，可以是 http(s)、oss:// 地址或本地路径，本地文件会先上传到临时存储
+	LanguageHints      []string // 语言提示，如 zh、en
+	DiarizationEnabled bool     // 是否区分说话人
+	SpeakerCount       int      // 说话人数量，开启区分说话人时有效，0表示自动判断
+	Poll               TaskPoll // 轮询任务状态的间隔，零值使用 WaitTask 的默认值
+}
+
+// Sentence 识别出的句子，时间单位为毫秒
+type Sentence struct {
+	ID        int    `json:"sentence_id"`
+	BeginTime int    `json:"begin_time"`
+	EndTime   int    `json:"end_time"`
+	Text      string `json:"text"`
+	SpeakerID *int   `json:"speaker_id,omitempty"` // 开启区分说话人时返回
+}
+
+// Transcript 单个声道的识别结果
+type Transcript struct {
+	ChannelID         int        `json:"channel_id"`
+	ContentDurationMs int        `json:"content_duration_in_milliseconds"`
+	Text              string     `json:"text"`
+	Sentences         []Sentence `json:"sentences"`
+}
+
+// Transcription 单个音频文件的识别结果
+type Transcription struct {
+	FileURL    string `json:"file_url"`
+	Properties struct {
+		AudioFormat        string `json:"audio_format"`
+		OriginalDurationMs int    `json:"original_duration_in_milliseconds"`
+	} `json:"properties"`
+	Transcripts []Transcript `json:"transcripts"`
+}
+
+// Text 返回各声道识别文本，多个声道以换行分隔
+func (t *Transcription) Text() string {
+	texts := make([]string, len(t.Transcripts))
+	for i, tr := range t.Transcripts {
+		texts[i] = tr.Text
+	}
+	return strings.Join(texts, "\n")
+}
+
+// Transcribe 识别单个音频文件，audioFile 可以是URL或本地路径
+func (c *HTTPClient) Transcribe(ctx context.Context, audioFile string) (*Transcription, error) {
+	results, err := c.TranscribeWithOptions(ctx, TranscriptionRequest{Files: []string{audioFile}})
+	if err != nil {
+		return nil, err
+	}
+	return &results[0], nil
+}
+
+// TranscribeWithOptions 提交录音文件识别任务并等待结果，结果顺序与 Files 一致
+// 任一文件识别失败时返回 *TaskError
+func (c *HTTPClient) TranscribeWithOptions(ctx context.Context, req TranscriptionRequest) ([]Transcription, error) {
+	if len(req.Files) == 0 {
+		return nil, errors.New("transcription files are empty")
+	}
+	if req.Model == "" {
+		req.Model = DefaultTranscriptionModel
+	}
+
+	header := http.Header{}
+	urls := make([]string, len(req.Files))
+	for i, file := range req.Files {
+		if !strings.Contains(file, "://") {
+			u, err := c.UploadTemporaryFile(ctx, req.Model, file)
+			if err != nil {
+				return nil, err
+			}
+			file = u
+		}
+		if strings.HasPrefix(file, "oss://") {
+			header.Set("X-DashScope-OssResourceResolve", "enable")
+		}
+		urls[i] = file
+	}
+
+	task, err := c.submitTask(ctx, transcriptionPath, transcriptionPayload(req, urls), header)
+	if err != nil {
+		return nil, err
+	}
+	if !task.Done() {
+		task, err = c.WaitTask(ctx, task.ID, req.Poll)
+	}
+	if err == nil {
+		err = task.Err()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var output struct {
+		Results []transcriptionResult `json:"results"`
+	}
+	if err := json.Unmarshal(task.Output, &output); err != nil {
+		return nil, err
+	}
+
+	// 结果不保证与提交顺序一致，按 file_url 对应到输入文件
+	byURL := make(map[string][]transcriptionResult, len(output.Results))
+	for _, r := range output.Results {
+		byURL[r.FileURL] = append(byURL[r.FileURL], r)
+	}
+
+	results := make([]Transcription, len(urls))
+	for i, u := range urls {
+		matched := byURL[u]
+		if len(matched) == 0 {
+			return nil, fmt.Errorf("task %s returned no result for %s", task.ID, req.Files[i])
+		}
+		r := matched[0]
+		byURL[u] = matched[1:]
+
+		if r.SubtaskStatus != TaskSucceeded {
+			return nil, &TaskError{TaskID: task.ID, Status: r.SubtaskStatus, Code: r.Code,
+				Message: fmt.Sprintf("%s: %s", req.Files[i], r.Message)}
+		}
+		if err := c.getJSON(ctx, r.TranscriptionURL, &results[i]); err != nil {
+			return nil, fmt.Errorf("download transcription of %s: %w", req.Files[i], err)
+		}
+	}
+	return results, nil
+}
+
+// transcriptionResult 录音文件识别任务中单个文件的结果
+type transcriptionResult struct {
+	FileURL          string `json:"file_url"`
+	TranscriptionURL string `json:"transcription_url"`
+	SubtaskStatus    string `json:"subtask_status"`
+	Code             string `json:"code"`
+	Message          string `json:"message"`
+}
+
+// transcriptionPayload 构造录音文件识别接口的请求体
+func transcriptionPayload(req TranscriptionRequest, urls []string) map[string]interface{} {
+	parameters := map[string]interface{}{}
+	if len(req.LanguageHints) > 0 {
+		parameters["language_hints"] = req.LanguageHints
+	}
+	if req.DiarizationEnabled {
+		parameters["diarization_enabled"] = true
+		if req.SpeakerCount > 0 {
+			parameters["speaker_count"] = req.SpeakerCount
+		}
+	}
+
+	return map[string]interface{}{
+		"model":      req.Model,
+		"input":      map[string]interface{}{"file_urls": urls},
+		"parameters": parameters,
+	}
+}
+
+// getJSON 下载结果URL中的JSON，结果URL自带签名，不需要鉴权头
+func (c *HTTPClient) getJSON(ctx context.Context, rawURL string, out interface{}) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return err
+	}
+	return c.doRequest(httpReq, out)
+}
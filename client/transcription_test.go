@@ -0,0 +1,257 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/config"
+)
+
+// fakeTranscriptionService 按 testdata/transcription 中录制的响应回放临时上传与录音文件识别接口
+type fakeTranscriptionService struct {
+	t    *testing.T
+	url  string
+	done string // 任务结束时返回的 fixture
+
+	mu       sync.Mutex
+	uploaded map[string]string // OSS key -> 文件内容
+	polls    int
+	submit   map[string]interface{}
+	header   http.Header
+}
+
+func newFakeTranscriptionService(t *testing.T, done string) (*fakeTranscriptionService, *HTTPClient) {
+	s := &fakeTranscriptionService{t: t, done: done, uploaded: map[string]string{}}
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	s.url = srv.URL
+
+	c := NewHTTPClient(&config.Config{APIKey: "test-key", BaseURL: srv.URL, NativeBaseURL: srv.URL + "/api/v1"})
+	return s, c
+}
+
+// fixture 读取录制的响应并替换其中的占位符
+func (s *fakeTranscriptionService) fixture(name string) string {
+	data, err := os.ReadFile(filepath.Join("testdata", "transcription", name))
+	if err != nil {
+		s.t.Errorf("read fixture: %v", err)
+		return "{}"
+	}
+	return strings.NewReplacer(
+		"{{server}}", s.url,
+		"{{oss_url}}", "oss://dashscope-instant/0a1b2c3d4e5f/2025-01-01/7c9e6679/local.wav",
+	).Replace(string(data))
+}
+
+func (s *fakeTranscriptionService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 只有 DashScope 接口需要鉴权，OSS 与结果地址自带签名
+	authorized := r.Header.Get("Authorization") == "Bearer test-key"
+	if strings.HasPrefix(r.URL.Path, "/api/v1/") != authorized {
+		s.t.Errorf("%s %s: Authorization = %q", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+	}
+
+	switch {
+	case r.Method == "GET" && r.URL.Path == "/api/v1/uploads":
+		q := r.URL.Query()
+		if q.Get("action") != "getPolicy" || q.Get("model") != DefaultTranscriptionModel {
+			s.t.Errorf("getPolicy query = %s", r.URL.RawQuery)
+		}
+		io.WriteString(w, s.fixture("upload_policy.json"))
+
+	case r.Method == "POST" && r.URL.Path == "/oss":
+		s.receiveUpload(w, r)
+
+	case r.Method == "POST" && r.URL.Path == "/api/v1/services/audio/asr/transcription":
+		s.header = r.Header.Clone()
+		if err := json.NewDecoder(r.Body).Decode(&s.submit); err != nil {
+			s.t.Errorf("decode submit: %v", err)
+		}
+		io.WriteString(w, s.fixture("submit.json"))
+
+	case r.Method == "GET" && r.URL.Path == "/api/v1/tasks/8d47c5b2-0d6f-4a2f-9c5b-3e1a7d9f2c10":
+		s.polls++
+		if s.polls < 2 {
+			io.WriteString(w, s.fixture("task_running.json"))
+			return
+		}
+		io.WriteString(w, s.fixture(s.done))
+
+	case r.Method == "GET" && strings.HasPrefix(r.URL.Path, "/results/"):
+		io.WriteString(w, s.fixture(strings.TrimPrefix(r.URL.Path, "/results/")))
+
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// receiveUpload 校验 OSS 表单上传，文件字段必须位于最后
+func (s *fakeTranscriptionService) receiveUpload(w http.ResponseWriter, r *http.Request) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		s.t.Errorf("multipart: %v", err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	fields := map[string]string{}
+	var names []string
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.t.Errorf("next part: %v", err)
+			return
+		}
+		data, _ := io.ReadAll(part)
+		names = append(names, part.FormName())
+		fields[part.FormName()] = string(data)
+	}
+
+	if len(names) == 0 || names[len(names)-1] != "file" {
+		s.t.Errorf("form fields = %v, file must be last", names)
+	}
+	for field, want := range map[string]string{
+		"OSSAccessKeyId":         "LTAI5tTestAccessKeyId",
+		"Signature":              "g5K2mJ1t3y0pQvX1xZl6bS8e9Pw=",
+		"policy":                 "eyJleHBpcmF0aW9uIjoiMjAyNS0wMS0wMVQwMDowMDowMC4wMDBaIn0=",
+		"x-oss-object-acl":       "private",
+		"x-oss-forbid-overwrite": "true",
+		"success_action_status":  "200",
+	} {
+		if fields[field] != want {
+			s.t.Errorf("form field %s = %q, want %q", field, fields[field], want)
+		}
+	}
+	s.uploaded[fields["key"]] = fields["file"]
+}
+
+// writeAudio 在临时目录写入一个本地音频文件
+func writeAudio(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "local.wav")
+	if err := os.WriteFile(path, []byte("RIFF fake wav data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUploadTemporaryFile(t *testing.T) {
+	s, c := newFakeTranscriptionService(t, "task_succeeded.json")
+	path := writeAudio(t)
+
+	u, err := c.UploadTemporaryFile(context.Background(), DefaultTranscriptionModel, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "dashscope-instant/0a1b2c3d4e5f/2025-01-01/7c9e6679/local.wav"
+	if u != "oss://"+key {
+		t.Errorf("url = %q", u)
+	}
+	if got := s.uploaded[key]; got != "RIFF fake wav data" {
+		t.Errorf("uploaded content = %q", got)
+	}
+}
+
+func TestTranscribe(t *testing.T) {
+	s, c := newFakeTranscriptionService(t, "task_succeeded.json")
+	path := writeAudio(t)
+
+	polls := 0
+	results, err := c.TranscribeWithOptions(context.Background(), TranscriptionRequest{
+		Files:              []string{"https://example.com/meeting.wav", path},
+		LanguageHints:      []string{"zh"},
+		DiarizationEnabled: true,
+		Poll: TaskPoll{
+			Interval: time.Millisecond,
+			OnPoll:   func(*Task) { polls++ },
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if polls != 2 {
+		t.Errorf("polled %d times, want 2", polls)
+	}
+
+	// 提交的请求
+	if got := s.header.Get("X-DashScope-Async"); got != "enable" {
+		t.Errorf("X-DashScope-Async = %q", got)
+	}
+	if got := s.header.Get("X-DashScope-OssResourceResolve"); got != "enable" {
+		t.Errorf("X-DashScope-OssResourceResolve = %q", got)
+	}
+	submit, _ := json.Marshal(s.submit)
+	for _, want := range []string{
+		`"model":"paraformer-v2"`,
+		`"file_urls":["https://example.com/meeting.wav","oss://dashscope-instant/0a1b2c3d4e5f/2025-01-01/7c9e6679/local.wav"]`,
+		`"language_hints":["zh"]`,
+		`"diarization_enabled":true`,
+	} {
+		if !strings.Contains(string(submit), want) {
+			t.Errorf("submit body %s does not contain %s", submit, want)
+		}
+	}
+
+	// 接口返回的结果顺序与提交顺序不同，按 file_url 对应回 Files 的顺序
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if got := results[0].Text(); got != "大家好，今天的会议开始。" {
+		t.Errorf("results[0].Text() = %q", got)
+	}
+	if got := results[1].Text(); got != "你好，世界。" {
+		t.Errorf("results[1].Text() = %q", got)
+	}
+	sentences := results[0].Transcripts[0].Sentences
+	if len(sentences) != 2 || sentences[1].SpeakerID == nil || *sentences[1].SpeakerID != 1 {
+		t.Errorf("sentences = %+v", sentences)
+	}
+	if results[0].Properties.OriginalDurationMs != 6120 {
+		t.Errorf("duration = %d", results[0].Properties.OriginalDurationMs)
+	}
+}
+
+func TestTranscribeSubtaskFailed(t *testing.T) {
+	_, c := newFakeTranscriptionService(t, "task_partial_failed.json")
+
+	_, err := c.TranscribeWithOptions(context.Background(), TranscriptionRequest{
+		Files: []string{"https://example.com/meeting.wav", "https://example.com/broken.wav"},
+		Poll:  TaskPoll{Interval: time.Millisecond},
+	})
+	var taskErr *TaskError
+	if !errors.As(err, &taskErr) {
+		t.Fatalf("err = %v, want *TaskError", err)
+	}
+	if taskErr.Status != TaskFailed || taskErr.Code != "InvalidFile.DownloadFailed" ||
+		!strings.Contains(taskErr.Message, "broken.wav") {
+		t.Errorf("task error = %+v", taskErr)
+	}
+}
+
+func TestTranscribeMissingResult(t *testing.T) {
+	_, c := newFakeTranscriptionService(t, "task_succeeded.json")
+
+	_, err := c.TranscribeWithOptions(context.Background(), TranscriptionRequest{
+		Files: []string{"https://example.com/meeting.wav", "https://example.com/other.wav"},
+		Poll:  TaskPoll{Interval: time.Millisecond},
+	})
+	if err == nil || !strings.Contains(err.Error(), "no result for https://example.com/other.wav") {
+		t.Fatalf("err = %v, want missing result error", err)
+	}
+}
@@ -14,27 +14,80 @@ type ChatRequest struct {
 	Temperature *float64  `json:"temperature,omitempty"`
 	TopP        *float64  `json:"top_p,omitempty"`
 	MaxTokens   *int      `json:"max_tokens,omitempty"`
+
+	PresencePenalty *float64 `json:"presence_penalty,omitempty"`
+	Seed            *int64   `json:"seed,omitempty"`
+	Stop            []string `json:"stop,omitempty"`
+
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
+	EnableThinking *bool           `json:"enable_thinking,omitempty"` // 深度思考，需要模型支持
+	EnableSearch   *bool           `json:"enable_search,omitempty"`   // 联网搜索，需要模型支持
+
+	StreamOptions *StreamOptions `json:"stream_options,omitempty"`
+
+	// DashScope 兼容模式不支持的原生参数，仅由 NativeClient 发送
+	DashScope *DashScopeParams `json:"-"`
+}
+
+// ResponseFormat 响应格式
+type ResponseFormat struct {
+	Type       string      `json:"type"` // text、json_object 或 json_schema
+	JSONSchema interface{} `json:"json_schema,omitempty"`
+}
+
+// StreamOptions 流式输出选项
+type StreamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// Usage Token使用情况
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+
+	PromptTokensDetails *PromptTokensDetails `json:"prompt_tokens_details,omitempty"`
+}
+
+// PromptTokensDetails 输入token明细
+type PromptTokensDetails struct {
+	CachedTokens int `json:"cached_tokens"` // 命中上下文缓存的token数
+}
+
+// CachedTokens 返回命中上下文缓存的输入token数
+func (u Usage) CachedTokens() int {
+	if u.PromptTokensDetails == nil {
+		return 0
+	}
+	return u.PromptTokensDetails.CachedTokens
 }
 
 // ChatResponse 聊天响应
 type ChatResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Index   int `json:"index"`
-		Message struct {
-			Role    string `json:"role"`
-			Content string `json:"content"`
-		} `json:"message"`
-		FinishReason string `json:"finish_reason"`
-	} `json:"choices"`
-	Usage struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
-		TotalTokens      int `json:"total_tokens"`
-	} `json:"usage"`
+	ID      string   `json:"id"`
+	Object  string   `json:"object"`
+	Created int64    `json:"created"`
+	Model   string   `json:"model"`
+	Choices []Choice `json:"choices"`
+	Usage   Usage    `json:"usage"`
+
+	SearchInfo *SearchInfo `json:"search_info,omitempty"` // 联网搜索来源，仅 NativeClient 返回
+
+	Meta ResponseMeta `json:"-"`
+}
+
+// Choice 聊天响应中的一个候选结果
+type Choice struct {
+	Index        int     `json:"index"`
+	Message      Message `json:"message"`
+	FinishReason string  `json:"finish_reason"`
+}
+
+// ResponseMeta 响应的本地元数据，不参与序列化
+type ResponseMeta struct {
+	Backend string // 实际提供响应的后端名称（由 FallbackClient 设置）
+	Hedged  bool   // 响应是否来自对冲请求
+	Cached  bool   // 响应是否来自缓存
 }
 
 // StreamChunk 流式响应块
@@ -51,6 +104,7 @@ type StreamChunk struct {
 		} `json:"delta"`
 		FinishReason *string `json:"finish_reason"`
 	} `json:"choices"`
+	Usage *Usage `json:"usage,omitempty"`
 }
 
 // StreamHandler 流式输出处理器
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+)
+
+// uploadPolicy DashScope 临时存储的上传凭证
+type uploadPolicy struct {
+	Policy              string `json:"policy"`
+	Signature           string `json:"signature"`
+	UploadDir           string `json:"upload_dir"`
+	UploadHost          string `json:"upload_host"`
+	ExpireInSeconds     int    `json:"expire_in_seconds"`
+	MaxFileSizeMB       int64  `json:"max_file_size_mb"`
+	OSSAccessKeyID      string `json:"oss_access_key_id"`
+	XOSSObjectACL       string `json:"x_oss_object_acl"`
+	XOSSForbidOverwrite string `json:"x_oss_forbid_overwrite"`
+}
+
+// UploadTemporaryFile 将本地文件上传到 DashScope 临时存储，返回 oss:// 地址
+// 地址仅对 model 指定的模型有效，48小时后过期；使用该地址的请求需要带
+// X-DashScope-OssResourceResolve: enable 请求头，本包的接口会自动添加
+func (c *HTTPClient) UploadTemporaryFile(ctx context.Context, model, path string) (string, error) {
+	if err := c.config.CheckDashScope(); err != nil {
+		return "", err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	var resp struct {
+		Data uploadPolicy `json:"data"`
+	}
+	query := url.Values{"action": {"getPolicy"}, "model": {model}}
+	if err := c.doJSON(ctx, "GET", c.config.DashScopeBaseURL()+"/uploads?"+query.Encode(), nil, &resp); err != nil {
+		return "", fmt.Errorf("get upload policy: %w", err)
+	}
+	policy := resp.Data
+	if policy.MaxFileSizeMB > 0 && info.Size() > policy.MaxFileSizeMB<<20 {
+		return "", fmt.Errorf("file %s exceeds upload limit of %dMB", path, policy.MaxFileSizeMB)
+	}
+
+	key := policy.UploadDir + "/" + filepath.Base(path)
+	fields := [][2]string{
+		{"OSSAccessKeyId", policy.OSSAccessKeyID},
+		{"Signature", policy.Signature},
+		{"policy", policy.Policy},
+		{"x-oss-object-acl", policy.XOSSObjectACL},
+		{"x-oss-forbid-overwrite", policy.XOSSForbidOverwrite},
+		{"key", key},
+		{"success_action_status", "200"},
+	}
+
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		err := func() error {
+			for _, field := range fields {
+				if err := mw.WriteField(field[0], field[1]); err != nil {
+					return err
+				}
+			}
+			// OSS 要求文件字段位于表单最后
+			part, err := mw.CreateFormFile("file", filepath.Base(path))
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(part, f); err != nil {
+				return err
+			}
+			return mw.Close()
+		}()
+		pw.CloseWithError(err)
+	}()
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", policy.UploadHost, pr)
+	if err != nil {
+		pr.Close()
+		return "", err
+	}
+	httpReq.Header.Set("Content-Type", mw.FormDataContentType())
+
+	if err := c.doRequest(httpReq, nil); err != nil {
+		return "", fmt.Errorf("upload %s: %w", path, err)
+	}
+	return "oss://" + key, nil
+}
@@ -0,0 +1,186 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/lvdashuaibi/GPTUtils/config"
+	"github.com/lvdashuaibi/GPTUtils/models"
+	"github.com/openai/openai-go"
+)
+
+// ValidationError 请求使用了模型不支持的能力或参数超出范围，请求未发送
+type ValidationError struct {
+	Model  string
+	Param  string
+	Reason string
+}
+
+// Error 实现 error 接口
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid %s for model %s: %s", e.Param, e.Model, e.Reason)
+}
+
+// requestFeatures 请求中需要校验的参数和用到的能力
+type requestFeatures struct {
+	temperature     *float64
+	topP            *float64
+	presencePenalty *float64
+	maxTokens       *int64
+	required        []requirement
+}
+
+// requirement 请求用到的能力及对应的参数名
+type requirement struct {
+	capability models.Capability
+	param      string
+}
+
+func (f *requestFeatures) require(c models.Capability, param string) {
+	f.required = append(f.required, requirement{capability: c, param: param})
+}
+
+// validate 按模型注册表校验请求，temperature 先按服务商上限截断再校验
+// 未注册的模型只在服务商为 DashScope 时按 models.DefaultRanges 校验参数范围，
+// 其他服务商的参数范围由服务端校验
+func validate(registry *models.Registry, model string, f requestFeatures, quirks config.Quirks) error {
+	f.temperature = clampTemperature(f.temperature, quirks)
+
+	m, known := registry.Lookup(model)
+	ranges := models.DefaultRanges
+	if known {
+		ranges = m.ParamRanges()
+	}
+	checkRanges := known || quirks.DashScope
+
+	for _, p := range []struct {
+		name  string
+		value *float64
+		r     models.Range
+	}{
+		{"temperature", f.temperature, ranges.Temperature},
+		{"top_p", f.topP, ranges.TopP},
+		{"presence_penalty", f.presencePenalty, ranges.PresencePenalty},
+	} {
+		if checkRanges && p.value != nil && !p.r.Contains(*p.value) {
+			return &ValidationError{
+				Model:  model,
+				Param:  p.name,
+				Reason: fmt.Sprintf("%g is out of range %s", *p.value, p.r),
+			}
+		}
+	}
+
+	if f.maxTokens != nil {
+		if *f.maxTokens <= 0 {
+			return &ValidationError{Model: model, Param: "max_tokens", Reason: "must be positive"}
+		}
+		if known && m.MaxOutputTokens > 0 && *f.maxTokens > int64(m.MaxOutputTokens) {
+			return &ValidationError{
+				Model:  model,
+				Param:  "max_tokens",
+				Reason: fmt.Sprintf("%d exceeds the model limit %d", *f.maxTokens, m.MaxOutputTokens),
+			}
+		}
+	}
+
+	if !known {
+		return nil
+	}
+	for _, r := range f.required {
+		if !m.Supports(r.capability) {
+			return &ValidationError{
+				Model:  model,
+				Param:  r.param,
+				Reason: fmt.Sprintf("model does not support %s", r.capability),
+			}
+		}
+	}
+	return nil
+}
+
+// validate 校验 HTTPClient 的请求
+func (c *HTTPClient) validate(req ChatRequest) error {
+	if err := checkNoDashScope(req); err != nil {
+		return err
+	}
+	return validate(c.registry, req.Model, chatRequestFeatures(req), c.config.ProviderQuirks())
+}
+
+// checkNoDashScope 兼容模式的客户端无法发送 DashScope 原生参数，设置了 req.DashScope 时返回错误而不是静默丢弃
+func checkNoDashScope(req ChatRequest) error {
+	if req.DashScope == nil {
+		return nil
+	}
+	return &ValidationError{Model: req.Model, Param: "dashscope", Reason: "native DashScope parameters require NativeClient"}
+}
+
+// clampTemperature 按服务商的 temperature 上限截断，返回新的指针，不修改原值
+func clampTemperature(t *float64, quirks config.Quirks) *float64 {
+	if quirks.MaxTemperature > 0 && t != nil && *t > quirks.MaxTemperature {
+		v := quirks.MaxTemperature
+		return &v
+	}
+	return t
+}
+
+// chatRequestFeatures 提取 ChatRequest 中需要校验的参数与能力
+func chatRequestFeatures(req ChatRequest) requestFeatures {
+	f := requestFeatures{
+		temperature:     req.Temperature,
+		topP:            req.TopP,
+		presencePenalty: req.PresencePenalty,
+	}
+	if req.MaxTokens != nil {
+		n := int64(*req.MaxTokens)
+		f.maxTokens = &n
+	}
+	if req.ResponseFormat != nil && req.ResponseFormat.Type != "" && req.ResponseFormat.Type != "text" {
+		f.require(models.JSONSchema, "response_format")
+	}
+	if req.EnableThinking != nil && *req.EnableThinking {
+		f.require(models.Thinking, "enable_thinking")
+	}
+	if req.EnableSearch != nil && *req.EnableSearch {
+		f.require(models.Search, "enable_search")
+	}
+	return f
+}
+
+// validate 校验 Client 的请求
+func (c *Client) validate(opts ChatOptions) error {
+	f := requestFeatures{
+		temperature:     opts.Temperature,
+		topP:            opts.TopP,
+		presencePenalty: opts.PresencePenalty,
+		maxTokens:       opts.MaxTokens,
+	}
+	if len(opts.Tools) > 0 {
+		f.require(models.Tools, "tools")
+	}
+	if rf := opts.ResponseFormat; rf != nil && rf.Type.Value != "" &&
+		rf.Type.Value != openai.ChatCompletionNewParamsResponseFormatTypeText {
+		f.require(models.JSONSchema, "response_format")
+	}
+	if opts.EnableThinking != nil && *opts.EnableThinking {
+		f.require(models.Thinking, "enable_thinking")
+	}
+	if opts.EnableSearch != nil && *opts.EnableSearch {
+		f.require(models.Search, "enable_search")
+	}
+	if hasImage(opts.Messages) {
+		f.require(models.Vision, "messages")
+	}
+
+	return validate(c.registry, opts.Model, f, c.config.ProviderQuirks())
+}
+
+// hasImage 判断消息中是否包含图片
+func hasImage(messages []openai.ChatCompletionMessageParamUnion) bool {
+	data, err := json.Marshal(messages)
+	if err != nil {
+		return false
+	}
+	return strings.Contains(string(data), `"image_url"`)
+}
@@ -7,42 +7,160 @@ import (
 	"fmt"
 	"github.com/lvdashuaibi/GPTUtils/client"
 	"github.com/lvdashuaibi/GPTUtils/config"
+	"github.com/lvdashuaibi/GPTUtils/conversation"
+	"github.com/lvdashuaibi/GPTUtils/conversation/sqlitestore"
+	"github.com/lvdashuaibi/GPTUtils/usage"
+	"io"
 	"log"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 )
 
+const systemPrompt = "你是一个友好、专业的AI助手"
+
 func main() {
 	// 命令行参数
 	stream := flag.Bool("stream", true, "使用流式输出(默认开启)")
-	model := flag.String("model", "qwen-plus", "模型名称")
+	provider := flag.String("provider", "dashscope", "服务商: "+providerNames())
+	baseURL := flag.String("base-url", "", "覆盖服务商的接口地址")
+	model := flag.String("model", "", "模型名称或别名，默认使用服务商的默认模型")
 	temperature := flag.Float64("temperature", 0.7, "采样温度(0-2)")
+	memory := flag.String("memory", "window", "历史记忆策略: full/last/window/summary")
+	memoryTurns := flag.Int("memory-turns", 10, "last 策略保留的对话轮数")
+	memoryTokens := flag.Int("memory-tokens", 8000, "window/summary 策略的token预算")
+	session := flag.String("session", "", "会话名称，设置后启动时加载并在每轮对话后自动保存")
+	sessionStore := flag.String("session-store", "file", "会话存储: file/sqlite")
+	sessionDir := flag.String("session-dir", defaultSessionDir(), "会话存储目录")
+	budget := flag.Float64("budget-cny", 0, "费用上限(元)，超出后拒绝请求，0表示不限")
 	flag.Parse()
 
-	// 创建配置
-	cfg := config.DefaultConfig()
-	cfg.Model = *model
+	// 按服务商创建配置
+	cfg, err := config.ProviderConfig(*provider)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *baseURL != "" {
+		cfg.BaseURL = *baseURL
+	}
+	if *model != "" {
+		cfg.Model = *model
+	}
+	if cfg.Model == "" {
+		log.Fatalf("服务商 %s 没有默认模型，请通过 -model 指定", *provider)
+	}
+	cfg.Model = cfg.ResolveModel(cfg.Model)
 
 	// 创建客户端
 	c := client.NewHTTPClient(cfg)
 
+	// 统计用量和费用，流式输出同样计入
+	tracker := usage.NewTracker(nil)
+	if *budget > 0 {
+		tracker.WithBudget(usage.Budget{Name: "cli", MaxCNY: *budget})
+	}
+	c.Use(tracker)
+
+	strategy, err := newStrategy(c, *memory, *memoryTurns, *memoryTokens)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// 对话历史由 Conversation 管理
+	conv := conversation.New(c, systemPrompt).
+		WithStrategy(strategy).
+		WithRequest(client.ChatRequest{Model: cfg.Model, Temperature: temperature})
+
+	store, err := newSessionStore(*sessionStore, *sessionDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
 	ctx := context.Background()
 	scanner := bufio.NewScanner(os.Stdin)
 
-	// 消息历史
-	messages := []client.Message{
-		{Role: "system", Content: "你是一个友好、专业的AI助手"},
+	if *session != "" {
+		if err := loadSession(ctx, store, conv, *session); err == nil {
+			fmt.Printf("已加载会话: %s\n", *session)
+		} else if err != conversation.ErrSessionNotFound {
+			log.Fatal(err)
+		}
 	}
 
 	fmt.Println("=== 通义千问对话工具 ===")
-	fmt.Printf("模型: %s\n", *model)
+	fmt.Printf("服务商: %s  模型: %s\n", cfg.Provider.Name, cfg.Model)
 	fmt.Printf("流式输出: %v\n", *stream)
 	fmt.Printf("温度: %.1f\n", *temperature)
+	fmt.Printf("记忆策略: %s\n", *memory)
+	if *session != "" {
+		fmt.Printf("会话: %s\n", *session)
+	}
 	fmt.Println("\n命令:")
-	fmt.Println("  exit/quit - 退出程序")
-	fmt.Println("  clear     - 清空对话历史")
-	fmt.Println("  history   - 查看对话历史")
-	fmt.Println("========================\n")
+	fmt.Println("  exit/quit       - 退出程序")
+	fmt.Println("  clear           - 清空对话历史")
+	fmt.Println("  history         - 查看对话历史")
+	fmt.Println("  /retry          - 重新生成最后一个回答")
+	fmt.Println("  /edit N 内容    - 编辑第N条用户消息并重新生成")
+	fmt.Println("  /branch [N [K]] - 列出分支/切换第N条消息的版本")
+	fmt.Println("  /usage          - 查看用量和费用")
+	fmt.Println("  /save [名称]    - 保存会话")
+	fmt.Println("  /load 名称      - 加载会话")
+	fmt.Println("  /sessions       - 列出会话")
+	fmt.Println("  /delete 名称    - 删除会话")
+	fmt.Println("========================")
+	fmt.Println()
+
+	// reply 调用模型生成回答并打印，成功后自动保存会话
+	reply := func(streamFn func(client.StreamHandler) (string, error), chatFn func() (*client.ChatResponse, error)) {
+		fmt.Print("AI: ")
+		before := tracker.Total()
+
+		if *stream {
+			// 流式输出
+			_, err := streamFn(func(chunk string) error {
+				fmt.Print(chunk)
+				return nil
+			})
+			if err != nil {
+				log.Printf("\n错误: %v\n", err)
+				return
+			}
+
+			fmt.Println()
+
+		} else {
+			// 普通对话
+			response, err := chatFn()
+			if err != nil {
+				log.Printf("\n错误: %v\n", err)
+				return
+			}
+
+			if len(response.Choices) > 0 {
+				fmt.Println(response.Choices[0].Message.Content)
+			}
+		}
+
+		// 显示Token使用情况
+		after := tracker.Total()
+		fmt.Printf("\n[Token使用: 输入=%d, 输出=%d, 总计=%d, 费用=¥%.4f]\n",
+			after.PromptTokens-before.PromptTokens,
+			after.CompletionTokens-before.CompletionTokens,
+			after.TotalTokens-before.TotalTokens,
+			after.Cost.CNY-before.Cost.CNY)
+
+		autosave(ctx, store, conv, *session)
+	}
+
+	regenerate := func() {
+		reply(func(h client.StreamHandler) (string, error) {
+			return conv.RegenerateStream(ctx, h)
+		}, func() (*client.ChatResponse, error) {
+			return conv.Regenerate(ctx)
+		})
+	}
 
 	for {
 		fmt.Print("你: ")
@@ -61,80 +179,284 @@ func main() {
 			fmt.Println("再见！")
 			return
 		case "clear":
-			messages = []client.Message{
-				{Role: "system", Content: "你是一个友好、专业的AI助手"},
-			}
+			conv.Reset()
 			fmt.Println("对话历史已清空")
 			continue
 		case "history":
 			fmt.Println("\n=== 对话历史 ===")
-			for i, msg := range messages {
-				if msg.Role == "system" {
-					continue
+			if s, ok := strategy.(*conversation.Summarizer); ok && s.Summary() != "" {
+				fmt.Printf("[摘要]: %s\n", s.Summary())
+			}
+			for i, msg := range conv.History() {
+				fmt.Printf("%d. [%s]: %s%s\n", i+1, msg.Role, msg.Content, branchMark(conv, i))
+			}
+			fmt.Println("================")
+			fmt.Println()
+			continue
+		}
+
+		if strings.HasPrefix(input, "/") {
+			fields := strings.Fields(input)
+			var err error
+			switch fields[0] {
+			case "/retry":
+				regenerate()
+			case "/edit":
+				var index int
+				if index, err = editCommand(conv, input); err == nil {
+					fmt.Printf("已编辑第%d条消息，重新生成回答\n", index+1)
+					regenerate()
 				}
-				fmt.Printf("%d. [%s]: %s\n", i, msg.Role, msg.Content)
+			case "/usage":
+				printUsage(tracker)
+			case "/branch":
+				if err = branchCommand(conv, fields[1:]); err == nil {
+					autosave(ctx, store, conv, *session)
+				}
+			default:
+				err = sessionCommand(ctx, store, conv, session, input)
+			}
+			if err != nil {
+				fmt.Printf("错误: %v\n", err)
 			}
-			fmt.Println("================\n")
+			fmt.Println()
 			continue
 		}
 
-		// 添加用户消息
-		messages = append(messages, client.Message{Role: "user", Content: input})
+		reply(func(h client.StreamHandler) (string, error) {
+			return conv.SendStream(ctx, input, h)
+		}, func() (*client.ChatResponse, error) {
+			return conv.Send(ctx, input)
+		})
 
-		fmt.Print("AI: ")
+		fmt.Println()
+	}
+}
 
-		if *stream {
-			// 流式输出
-			req := client.ChatRequest{
-				Messages:    messages,
-				Temperature: temperature,
-			}
+// printUsage 按模型打印本次运行的用量和费用
+func printUsage(tracker *usage.Tracker) {
+	for model, r := range tracker.ByModel() {
+		fmt.Printf("  %-20s 请求=%d 输入=%d(缓存%d) 输出=%d 费用=¥%.4f/$%.4f\n",
+			model, r.Requests, r.PromptTokens, r.CachedTokens, r.CompletionTokens, r.Cost.CNY, r.Cost.USD)
+	}
+	total := tracker.Total()
+	fmt.Printf("  %-20s 请求=%d 总计=%d 费用=¥%.4f/$%.4f\n",
+		"合计", total.Requests, total.TotalTokens, total.Cost.CNY, total.Cost.USD)
+}
 
-			var fullResponse strings.Builder
-			err := c.ChatStream(ctx, req, func(chunk string) error {
-				fmt.Print(chunk)
-				fullResponse.WriteString(chunk)
-				return nil
-			})
+// autosave 设置了会话名称时保存会话
+func autosave(ctx context.Context, store conversation.SessionStore, conv *conversation.Conversation, name string) {
+	if name == "" {
+		return
+	}
+	if err := store.Save(ctx, conv.Session(name)); err != nil {
+		log.Printf("保存会话失败: %v\n", err)
+	}
+}
 
+// editCommand 处理 "/edit N 新内容"，N 为 history 中的编号，返回消息下标
+func editCommand(conv *conversation.Conversation, input string) (int, error) {
+	fields := strings.SplitN(input, " ", 3)
+	if len(fields) < 3 {
+		return 0, fmt.Errorf("用法: /edit 编号 新内容")
+	}
+	n, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, fmt.Errorf("用法: /edit 编号 新内容")
+	}
+	return n - 1, conv.Edit(n-1, strings.TrimSpace(fields[2]))
+}
+
+// branchCommand 处理 /branch 命令：
+// 无参数时列出有多个版本的消息，"/branch N" 切换到第N条消息的下一个版本，"/branch N K" 切换到第K个版本
+func branchCommand(conv *conversation.Conversation, args []string) error {
+	history := conv.History()
+
+	if len(args) == 0 {
+		found := false
+		for i := range history {
+			alts, current, err := conv.Alternatives(i)
 			if err != nil {
-				log.Printf("\n错误: %v\n", err)
-				// 移除最后添加的用户消息
-				messages = messages[:len(messages)-1]
-				continue
+				return err
 			}
+			if len(alts) > 1 {
+				found = true
+				fmt.Printf("  %d. [%s] 版本 %d/%d: %s\n", i+1, history[i].Role, current+1, len(alts), preview(history[i].Content))
+			}
+		}
+		if !found {
+			fmt.Println("当前对话没有分支")
+		}
+		return nil
+	}
 
-			fmt.Println()
-			// 添加AI回复到历史
-			messages = append(messages, client.Message{Role: "assistant", Content: fullResponse.String()})
+	n, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("用法: /branch [编号 [版本]]")
+	}
+	alts, current, err := conv.Alternatives(n - 1)
+	if err != nil {
+		return err
+	}
 
-		} else {
-			// 普通对话
-			req := client.ChatRequest{
-				Messages:    messages,
-				Temperature: temperature,
-			}
+	alt := (current + 1) % len(alts)
+	if len(args) > 1 {
+		k, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("用法: /branch [编号 [版本]]")
+		}
+		alt = k - 1
+	}
+	if err := conv.SwitchBranch(n-1, alt); err != nil {
+		return err
+	}
 
-			response, err := c.Chat(ctx, req)
-			if err != nil {
-				log.Printf("\n错误: %v\n", err)
-				messages = messages[:len(messages)-1]
-				continue
-			}
+	fmt.Printf("第%d条消息已切换到版本 %d/%d\n", n, alt+1, len(alts))
+	history = conv.History()
+	if len(history) > 0 {
+		last := history[len(history)-1]
+		fmt.Printf("%d. [%s]: %s\n", len(history), last.Role, last.Content)
+	}
+	return nil
+}
 
-			if len(response.Choices) > 0 {
-				content := response.Choices[0].Message.Content
-				fmt.Println(content)
-				messages = append(messages, client.Message{Role: "assistant", Content: content})
-
-				// 显示Token使用情况
-				fmt.Printf("\n[Token使用: 输入=%d, 输出=%d, 总计=%d]\n",
-					response.Usage.PromptTokens,
-					response.Usage.CompletionTokens,
-					response.Usage.TotalTokens)
-			}
+// branchMark 返回 history 中第i条消息的版本标记，只有一个版本时为空
+func branchMark(conv *conversation.Conversation, i int) string {
+	alts, current, err := conv.Alternatives(i)
+	if err != nil || len(alts) <= 1 {
+		return ""
+	}
+	return fmt.Sprintf(" (版本 %d/%d)", current+1, len(alts))
+}
+
+// preview 截取内容开头用于列表显示
+func preview(content string) string {
+	runes := []rune(strings.ReplaceAll(content, "\n", " "))
+	if len(runes) > 40 {
+		return string(runes[:40]) + "..."
+	}
+	return string(runes)
+}
+
+// newStrategy 根据命令行参数创建记忆策略
+func newStrategy(c *client.HTTPClient, name string, turns, tokens int) (conversation.Strategy, error) {
+	switch name {
+	case "full":
+		return nil, nil
+	case "last":
+		return conversation.NewLastN(turns), nil
+	case "window":
+		return conversation.NewTokenWindow(tokens), nil
+	case "summary":
+		return conversation.NewSummarizer(c, tokens), nil
+	default:
+		return nil, fmt.Errorf("unknown memory strategy: %s", name)
+	}
+}
+
+// defaultSessionDir 默认会话存储目录 ~/.gptutils/sessions
+func defaultSessionDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "sessions"
+	}
+	return filepath.Join(home, ".gptutils", "sessions")
+}
+
+// sessionStore 可关闭的会话存储，退出时关闭
+type sessionStore interface {
+	conversation.SessionStore
+	io.Closer
+}
+
+// newSessionStore 根据命令行参数创建会话存储
+func newSessionStore(kind, dir string) (sessionStore, error) {
+	switch kind {
+	case "file":
+		return conversation.NewFileStore(dir)
+	case "sqlite":
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return nil, err
 		}
+		return sqlitestore.Open(filepath.Join(dir, "sessions.db"))
+	default:
+		return nil, fmt.Errorf("unknown session store: %s", kind)
+	}
+}
 
-		fmt.Println()
+func loadSession(ctx context.Context, store conversation.SessionStore, conv *conversation.Conversation, name string) error {
+	s, err := store.Load(ctx, name)
+	if err != nil {
+		return err
+	}
+	return conv.Restore(s)
+}
+
+// sessionCommand 处理 /save、/load、/sessions、/delete 命令
+func sessionCommand(ctx context.Context, store conversation.SessionStore, conv *conversation.Conversation, current *string, input string) error {
+	fields := strings.Fields(input)
+	name := ""
+	if len(fields) > 1 {
+		name = fields[1]
+	}
+
+	switch fields[0] {
+	case "/save":
+		if name == "" {
+			name = *current
+		}
+		if name == "" {
+			return fmt.Errorf("用法: /save 名称")
+		}
+		if err := store.Save(ctx, conv.Session(name)); err != nil {
+			return err
+		}
+		*current = name
+		fmt.Printf("会话已保存: %s\n", name)
+	case "/load":
+		if name == "" {
+			return fmt.Errorf("用法: /load 名称")
+		}
+		if err := loadSession(ctx, store, conv, name); err != nil {
+			return err
+		}
+		*current = name
+		fmt.Printf("已加载会话: %s（%d 条消息）\n", name, len(conv.History()))
+	case "/sessions":
+		infos, err := store.List(ctx)
+		if err != nil {
+			return err
+		}
+		if len(infos) == 0 {
+			fmt.Println("暂无会话")
+		}
+		for _, info := range infos {
+			fmt.Printf("  %-20s %-16s %4d 条消息  %6d tokens  %s\n",
+				info.Name, info.Model, info.Messages, info.Usage.TotalTokens,
+				info.UpdatedAt.Format("2006-01-02 15:04"))
+		}
+	case "/delete":
+		if name == "" {
+			return fmt.Errorf("用法: /delete 名称")
+		}
+		if err := store.Delete(ctx, name); err != nil {
+			return err
+		}
+		if name == *current {
+			*current = ""
+		}
+		fmt.Printf("会话已删除: %s\n", name)
+	default:
+		return fmt.Errorf("未知命令: %s", fields[0])
+	}
+	return nil
+}
+
+// providerNames 返回可选服务商名称，用于参数说明
+func providerNames() string {
+	var names []string
+	for _, p := range config.Providers() {
+		names = append(names, p.Name)
 	}
+	return strings.Join(names, "/")
 }
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/batch"
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// runBatch 实现 batch 子命令
+func runBatch(args []string) error {
+	if len(args) == 0 {
+		printBatchUsage()
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch args[0] {
+	case "submit":
+		return batchSubmit(ctx, args[1:])
+	case "status":
+		return batchStatus(ctx, args[1:])
+	case "fetch":
+		return batchFetch(ctx, args[1:])
+	case "cancel":
+		return batchCancel(ctx, args[1:])
+	case "run":
+		return batchRun(ctx, args[1:])
+	default:
+		printBatchUsage()
+		return fmt.Errorf("unknown batch command: %s", args[0])
+	}
+}
+
+func printBatchUsage() {
+	fmt.Println("用法: gptutils batch <命令> [参数]")
+	fmt.Println()
+	fmt.Println("命令:")
+	fmt.Println("  submit [-model 模型] [-wait] 输入.jsonl   上传请求并创建批处理任务")
+	fmt.Println("  status [任务ID]                          查看任务状态，不指定ID时列出最近的任务")
+	fmt.Println("  fetch [-o 输出.jsonl] [-input 输入.jsonl] 任务ID  下载结果")
+	fmt.Println("  cancel 任务ID                            取消任务")
+	fmt.Println("  run [-c 并发] [-rpm 每分钟请求数] -o 输出.jsonl 输入.jsonl  在本地直接执行，支持中断后续跑")
+	fmt.Println()
+	fmt.Println(`输入文件每行一个请求: {"custom_id": "1", "body": {"messages": [{"role": "user", "content": "你好"}]}}`)
+}
+
+func batchSubmit(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("batch submit", flag.ExitOnError)
+	model := fs.String("model", "", "请求未指定模型时使用的模型，默认使用配置中的模型")
+	window := fs.String("window", "24h", "完成时限")
+	wait := fs.Bool("wait", false, "等待任务结束")
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: gptutils batch submit [-model 模型] [-wait] 输入.jsonl")
+	}
+
+	reqs, err := batch.ReadRequestsFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	if *model == "" {
+		*model = cfg.Model
+	}
+	for i := range reqs {
+		if reqs[i].Body.Model == "" {
+			reqs[i].Body.Model = *model
+		}
+	}
+
+	c := client.NewHTTPClient(cfg)
+	b, err := batch.Submit(ctx, c, reqs, batch.SubmitOptions{CompletionWindow: *window})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已创建批处理任务: %s（%d 个请求）\n", b.ID, len(reqs))
+
+	if *wait {
+		b, err = batch.Wait(ctx, c, b.ID, 30*time.Second, printProgress)
+		fmt.Println()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("任务结束: %s\n", b.Status)
+	}
+	return nil
+}
+
+func batchStatus(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("batch status", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "以JSON格式输出")
+	provider := providerFlag(fs)
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+
+	if fs.NArg() == 0 {
+		batches, err := c.ListBatches(ctx, "", 20)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return writeJSON(os.Stdout, batches)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tSTATUS\tPROGRESS\tCREATED")
+		for _, b := range batches {
+			fmt.Fprintf(w, "%s\t%s\t%d/%d\t%s\n", b.ID, b.Status,
+				b.RequestCounts.Completed+b.RequestCounts.Failed, b.RequestCounts.Total,
+				time.Unix(b.CreatedAt, 0).Format("2006-01-02 15:04"))
+		}
+		return w.Flush()
+	}
+
+	b, err := c.GetBatch(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(os.Stdout, b)
+	}
+
+	fmt.Printf("ID:       %s\n", b.ID)
+	fmt.Printf("状态:     %s\n", b.Status)
+	fmt.Printf("进度:     成功 %d，失败 %d，共 %d\n", b.RequestCounts.Completed, b.RequestCounts.Failed, b.RequestCounts.Total)
+	fmt.Printf("创建时间: %s\n", time.Unix(b.CreatedAt, 0).Format(time.DateTime))
+	if b.OutputFileID != "" {
+		fmt.Printf("输出文件: %s\n", b.OutputFileID)
+	}
+	if b.ErrorFileID != "" {
+		fmt.Printf("错误文件: %s\n", b.ErrorFileID)
+	}
+	if b.Errors != nil {
+		for _, e := range b.Errors.Data {
+			fmt.Printf("错误:     %s %s (第%d行)\n", e.Code, e.Message, e.Line)
+		}
+	}
+	return nil
+}
+
+// fetchedLine fetch 命令在提供了输入文件时输出的行
+type fetchedLine struct {
+	CustomID string        `json:"custom_id"`
+	Content  string        `json:"content,omitempty"`
+	Error    string        `json:"error,omitempty"`
+	Usage    *client.Usage `json:"usage,omitempty"`
+}
+
+func batchFetch(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("batch fetch", flag.ExitOnError)
+	output := fs.String("o", "", "输出文件，默认输出到标准输出")
+	input := fs.String("input", "", "输入文件，提供时按 custom_id 对应输出回答内容，否则输出原始结果")
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: gptutils batch fetch [-o 输出.jsonl] [-input 输入.jsonl] 任务ID")
+	}
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	b, err := c.GetBatch(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if !b.Done() {
+		return fmt.Errorf("batch %s is still %s", b.ID, b.Status)
+	}
+
+	results, err := batch.Fetch(ctx, c, b)
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+
+	if *input == "" {
+		for _, res := range results {
+			if err := enc.Encode(res); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	reqs, err := batch.ReadRequestsFile(*input)
+	if err != nil {
+		return err
+	}
+
+	missing := 0
+	for _, pair := range batch.Join(reqs, results) {
+		line := fetchedLine{CustomID: pair.Request.CustomID}
+		switch {
+		case pair.Result == nil:
+			line.Error = "no result"
+			missing++
+		case pair.Result.Err() != nil:
+			line.Error = pair.Result.Err().Error()
+		default:
+			line.Content = pair.Result.Content()
+			line.Usage = &pair.Result.Response.Body.Usage
+		}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	if missing > 0 {
+		fmt.Fprintf(os.Stderr, "%d 个请求没有结果\n", missing)
+	}
+	return nil
+}
+
+func batchCancel(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("batch cancel", flag.ExitOnError)
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: gptutils batch cancel 任务ID")
+	}
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	b, err := c.CancelBatch(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("任务 %s 状态: %s\n", b.ID, b.Status)
+	return nil
+}
+
+func batchRun(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("batch run", flag.ExitOnError)
+	concurrency := fs.Int("c", 4, "并发请求数")
+	rpm := fs.Int("rpm", 0, "每分钟请求数上限，0表示不限制")
+	tpm := fs.Int("tpm", 0, "每分钟token数上限，0表示不限制")
+	model := fs.String("model", "", "请求未指定模型时使用的模型，默认使用配置中的模型")
+	output := fs.String("o", "", "输出文件，已存在时跳过其中成功的请求")
+	quiet := fs.Bool("q", false, "不显示进度条")
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 || *output == "" {
+		return fmt.Errorf("用法: gptutils batch run [-c 并发] [-rpm 每分钟请求数] -o 输出.jsonl 输入.jsonl")
+	}
+
+	reqs, err := batch.ReadRequestsFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	if *rpm > 0 || *tpm > 0 {
+		c.WithRateLimiter(client.NewRateLimiter(client.RateLimiterConfig{
+			Default: client.RateLimit{RPM: *rpm, TPM: *tpm},
+		}))
+	}
+
+	opts := batch.RunnerOptions{Concurrency: *concurrency, Model: *model}
+	if !*quiet {
+		opts.Progress = os.Stderr
+	}
+	stats, err := batch.NewRunner(c, opts).Run(ctx, reqs, *output)
+	if stats != nil {
+		fmt.Fprintln(os.Stderr, stats)
+	}
+	if err != nil {
+		return err
+	}
+	if stats.Failed > 0 {
+		return fmt.Errorf("%d 个请求失败，重新运行可重试失败的请求", stats.Failed)
+	}
+	return nil
+}
+
+// printProgress 在同一行刷新任务进度
+func printProgress(b *client.Batch) {
+	fmt.Printf("\r%s: %d/%d (失败 %d)   ", b.Status,
+		b.RequestCounts.Completed+b.RequestCounts.Failed, b.RequestCounts.Total, b.RequestCounts.Failed)
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"flag"
+	"strings"
+
+	"github.com/lvdashuaibi/GPTUtils/config"
+)
+
+// providerFlag 为子命令注册共用的 -provider 参数
+func providerFlag(fs *flag.FlagSet) *string {
+	return fs.String("provider", config.ProviderDashScope.Name, "服务商: "+providerNames())
+}
+
+// loadConfig 按服务商创建配置，未设置 API Key 等错误返回给 main 统一输出
+func loadConfig(provider string) (*config.Config, error) {
+	return config.ProviderConfig(provider)
+}
+
+// providerNames 返回可选服务商名称，用于参数说明
+func providerNames() string {
+	var names []string
+	for _, p := range config.Providers() {
+		names = append(names, p.Name)
+	}
+	return strings.Join(names, "/")
+}
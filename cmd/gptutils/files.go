@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// runFiles 实现 files 子命令
+func runFiles(args []string) error {
+	if len(args) == 0 {
+		printFilesUsage()
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	switch args[0] {
+	case "list":
+		return filesList(ctx, args[1:])
+	case "upload":
+		return filesUpload(ctx, args[1:])
+	case "info":
+		return filesInfo(ctx, args[1:])
+	case "delete":
+		return filesDelete(ctx, args[1:])
+	case "ask":
+		return filesAsk(ctx, args[1:])
+	default:
+		printFilesUsage()
+		return fmt.Errorf("unknown files command: %s", args[0])
+	}
+}
+
+func printFilesUsage() {
+	fmt.Println("用法: gptutils files <命令> [参数]")
+	fmt.Println()
+	fmt.Println("命令:")
+	fmt.Println("  list [-purpose 用途]                  列出已上传的文件")
+	fmt.Println("  upload [-purpose 用途] 文件...         上传文件，默认用途 file-extract")
+	fmt.Println("  info 文件ID                           查看文件信息")
+	fmt.Println("  delete 文件ID...                      删除文件")
+	fmt.Println("  ask [-model qwen-long] [-keep] 文档... 问题  上传文档并提问，结束后删除上传的文档")
+}
+
+func filesList(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("files list", flag.ExitOnError)
+	purpose := fs.String("purpose", "", "只列出该用途的文件")
+	asJSON := fs.Bool("json", false, "以JSON格式输出")
+	provider := providerFlag(fs)
+	fs.Parse(args)
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	files, err := c.ListFiles(ctx, *purpose)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(os.Stdout, files)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tFILENAME\tBYTES\tPURPOSE\tCREATED")
+	for _, f := range files {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\n", f.ID, f.Filename, f.Bytes, f.Purpose,
+			time.Unix(f.CreatedAt, 0).Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
+
+func filesUpload(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("files upload", flag.ExitOnError)
+	purpose := fs.String("purpose", client.FilePurposeExtract, "文件用途")
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("用法: gptutils files upload [-purpose 用途] 文件...")
+	}
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	for _, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		file, err := c.UploadFile(ctx, filepath.Base(path), f, *purpose)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("upload %s: %w", path, err)
+		}
+		fmt.Printf("%s\t%s\n", file.ID, path)
+	}
+	return nil
+}
+
+func filesInfo(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("files info", flag.ExitOnError)
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: gptutils files info 文件ID")
+	}
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	file, err := c.GetFile(ctx, fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	return writeJSON(os.Stdout, file)
+}
+
+func filesDelete(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("files delete", flag.ExitOnError)
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("用法: gptutils files delete 文件ID...")
+	}
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	for _, id := range fs.Args() {
+		if err := c.DeleteFile(ctx, id); err != nil {
+			return err
+		}
+		fmt.Printf("已删除 %s\n", id)
+	}
+	return nil
+}
+
+func filesAsk(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("files ask", flag.ExitOnError)
+	model := fs.String("model", "qwen-long", "模型")
+	system := fs.String("system", "", "系统提示")
+	keep := fs.Bool("keep", false, "结束后保留上传的文档")
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() < 2 {
+		return fmt.Errorf("用法: gptutils files ask [-model qwen-long] [-keep] 文档... 问题")
+	}
+	paths, question := fs.Args()[:fs.NArg()-1], fs.Arg(fs.NArg()-1)
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	session := client.NewFileSession(c)
+	if !*keep {
+		defer func() {
+			if err := session.Close(); err != nil {
+				fmt.Fprintf(os.Stderr, "删除上传的文档失败: %v\n", err)
+			}
+		}()
+	}
+
+	for _, path := range paths {
+		file, err := session.Upload(ctx, path)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "已上传 %s (%s)\n", path, file.ID)
+	}
+
+	req := client.ChatRequest{Model: *model, Messages: session.Messages(*system, question)}
+	err = c.ChatStream(ctx, req, func(content string) error {
+		fmt.Print(content)
+		return nil
+	})
+	fmt.Println()
+	return err
+}
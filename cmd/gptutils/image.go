@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// runImage 实现 image 子命令
+func runImage(args []string) error {
+	fs := flag.NewFlagSet("image", flag.ExitOnError)
+	model := fs.String("model", client.DefaultImageModel, "文生图模型")
+	size := fs.String("size", "1024*1024", "图像尺寸，格式为 宽*高")
+	n := fs.Int("n", 1, "生成数量")
+	negative := fs.String("negative", "", "反向提示词")
+	output := fs.String("o", ".", "图像保存目录")
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: gptutils image [-model 模型] [-size 宽*高] [-n 数量] [-o 目录] 提示词")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	task, err := c.SubmitImage(ctx, client.ImageRequest{
+		Model:          *model,
+		Prompt:         fs.Arg(0),
+		NegativePrompt: *negative,
+		Size:           *size,
+		N:              *n,
+	})
+	if err != nil {
+		return err
+	}
+	id := task.ID
+	fmt.Fprintf(os.Stderr, "已提交任务: %s\n", id)
+
+	task, err = c.WaitTask(ctx, id, client.TaskPoll{
+		OnPoll: func(t *client.Task) {
+			fmt.Fprintf(os.Stderr, "\r%s   ", t.Status)
+		},
+	})
+	fmt.Fprintln(os.Stderr)
+	if ctx.Err() != nil {
+		// 中断时尝试取消仍在排队的任务
+		cancelCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := c.CancelTask(cancelCtx, id); err == nil {
+			fmt.Fprintf(os.Stderr, "已取消任务: %s\n", id)
+		}
+		return ctx.Err()
+	}
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.ParseImageTask(task)
+	if err != nil {
+		return err
+	}
+	paths, err := c.DownloadImages(ctx, resp, *output)
+	for i, r := range resp.Results {
+		switch {
+		case paths != nil && paths[i] != "":
+			fmt.Println(paths[i])
+		case r.Code != "":
+			fmt.Fprintf(os.Stderr, "第%d张生成失败: %s - %s\n", i+1, r.Code, r.Message)
+		}
+	}
+	return err
+}
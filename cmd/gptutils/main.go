@@ -0,0 +1,70 @@
+// gptutils 命令行工具
+//
+// 用法:
+//
+//	gptutils <命令> [参数]
+//
+// 命令:
+//
+//	models    列出可用模型
+//	batch     离线批量推理
+//	files     管理上传的文件、基于文档提问
+//	image     文生图
+//	tts       语音合成
+//	asr       录音文件识别
+//
+// 各子命令均可通过 -provider 选择服务商，默认为 dashscope。
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// command 子命令
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) error
+}
+
+var commands = []command{
+	{"models", "列出可用模型及其能力", runModels},
+	{"batch", "通过 Batch 接口离线批量推理", runBatch},
+	{"files", "管理上传的文件，基于文档向 qwen-long 提问", runFiles},
+	{"image", "通义万相文生图，结果保存到本地", runImage},
+	{"tts", "CosyVoice/Sambert 语音合成", runTTS},
+	{"asr", "Paraformer 录音文件识别", runASR},
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] == "-h" || os.Args[1] == "help" {
+		printUsage()
+		return
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == os.Args[1] {
+			if err := cmd.run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "错误: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "未知命令: %s\n\n", os.Args[1])
+	printUsage()
+	os.Exit(2)
+}
+
+func printUsage() {
+	fmt.Println("用法: gptutils <命令> [参数]")
+	fmt.Println()
+	fmt.Println("命令:")
+	for _, cmd := range commands {
+		fmt.Printf("  %-10s %s\n", cmd.name, cmd.usage)
+	}
+	fmt.Println()
+	fmt.Println("使用 gptutils <命令> -h 查看命令参数")
+}
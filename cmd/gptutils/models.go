@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+	"github.com/lvdashuaibi/GPTUtils/models"
+)
+
+// runModels 实现 models 子命令
+func runModels(args []string) error {
+	fs := flag.NewFlagSet("models", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "以JSON格式输出")
+	local := fs.Bool("local", false, "只列出本地注册表中的模型，不调用接口")
+	provider := providerFlag(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "用法: gptutils models [参数] [模型ID]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	var infos []client.ModelInfo
+	if *local {
+		infos = localModels()
+	} else {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		cfg, err := loadConfig(*provider)
+		if err != nil {
+			return err
+		}
+		c := client.NewHTTPClient(cfg)
+		if id := fs.Arg(0); id != "" {
+			info, err := c.GetModel(ctx, id)
+			if err != nil {
+				return err
+			}
+			infos = []client.ModelInfo{*info}
+		} else {
+			var err error
+			if infos, err = c.ListModels(ctx); err != nil {
+				return err
+			}
+		}
+	}
+
+	if *asJSON {
+		return writeJSON(os.Stdout, infos)
+	}
+	printModels(infos)
+	return nil
+}
+
+// localModels 将本地注册表转换为模型信息
+func localModels() []client.ModelInfo {
+	var infos []client.ModelInfo
+	for _, m := range models.List() {
+		infos = append(infos, client.ModelInfo{
+			ID:              m.ID,
+			Registered:      true,
+			ContextWindow:   m.ContextWindow,
+			MaxInputTokens:  m.InputLimit(),
+			MaxOutputTokens: m.MaxOutputTokens,
+			Capabilities:    m.Capabilities,
+		})
+	}
+	return infos
+}
+
+// printModels 以表格形式输出模型
+func printModels(infos []client.ModelInfo) {
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCONTEXT\tMAX INPUT\tMAX OUTPUT\tCAPABILITIES\tOWNER")
+	for _, info := range infos {
+		caps := info.Capabilities.String()
+		if !info.Registered {
+			caps = "(unknown)"
+		} else if caps == "" {
+			caps = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			info.ID,
+			tokens(info.ContextWindow),
+			tokens(info.MaxInputTokens),
+			tokens(info.MaxOutputTokens),
+			caps,
+			info.OwnedBy)
+	}
+	w.Flush()
+}
+
+// tokens 格式化token数，0显示为 -
+func tokens(n int) string {
+	switch {
+	case n == 0:
+		return "-"
+	case n >= 1000000 && n%1000000 == 0:
+		return strconv.Itoa(n/1000000) + "M"
+	case n >= 1024 && n%1024 == 0:
+		return strconv.Itoa(n/1024) + "K"
+	default:
+		return strconv.Itoa(n)
+	}
+}
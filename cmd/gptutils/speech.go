@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// runTTS 实现 tts 子命令
+func runTTS(args []string) error {
+	fs := flag.NewFlagSet("tts", flag.ExitOnError)
+	model := fs.String("model", client.DefaultSpeechModel, "语音合成模型，Sambert 模型名包含音色，如 sambert-zhichu-v1")
+	voice := fs.String("voice", "longxiaochun", "CosyVoice 音色")
+	format := fs.String("format", client.AudioMP3, "音频格式: mp3、wav、pcm")
+	output := fs.String("o", "", "输出文件，默认输出到标准输出")
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return fmt.Errorf("用法: gptutils tts [-voice 音色] [-format mp3] [-o 输出文件] 文本")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	req := client.SpeechRequest{Model: *model, Text: fs.Arg(0), Voice: *voice, Format: *format}
+	if strings.HasPrefix(*model, "sambert") {
+		req.Voice = ""
+	}
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	audio, err := c.SynthesizeWithOptions(ctx, req)
+	if err != nil {
+		return err
+	}
+	defer audio.Close()
+
+	var w io.Writer = os.Stdout
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	n, err := io.Copy(w, audio)
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "已保存 %s（%d 字节）\n", *output, n)
+	}
+	return nil
+}
+
+// runASR 实现 asr 子命令
+func runASR(args []string) error {
+	fs := flag.NewFlagSet("asr", flag.ExitOnError)
+	model := fs.String("model", client.DefaultTranscriptionModel, "录音文件识别模型")
+	lang := fs.String("lang", "", "语言提示，多个以逗号分隔，如 zh,en")
+	speakers := fs.Bool("speakers", false, "区分说话人")
+	timestamps := fs.Bool("t", false, "按句输出时间戳")
+	asJSON := fs.Bool("json", false, "以JSON格式输出完整结果")
+	provider := providerFlag(fs)
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return fmt.Errorf("用法: gptutils asr [-lang zh,en] [-speakers] [-t] 音频文件或URL...")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	req := client.TranscriptionRequest{
+		Model:              *model,
+		Files:              fs.Args(),
+		DiarizationEnabled: *speakers,
+	}
+	if *lang != "" {
+		req.LanguageHints = strings.Split(*lang, ",")
+	}
+
+	cfg, err := loadConfig(*provider)
+	if err != nil {
+		return err
+	}
+	c := client.NewHTTPClient(cfg)
+	results, err := c.TranscribeWithOptions(ctx, req)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		return writeJSON(os.Stdout, results)
+	}
+
+	for i, r := range results {
+		if len(results) > 1 {
+			fmt.Printf("== %s\n", fs.Arg(i))
+		}
+		if !*timestamps && !*speakers {
+			fmt.Println(r.Text())
+			continue
+		}
+		for _, tr := range r.Transcripts {
+			for _, s := range tr.Sentences {
+				fmt.Printf("[%s - %s]", formatMs(s.BeginTime), formatMs(s.EndTime))
+				if s.SpeakerID != nil {
+					fmt.Printf(" 说话人%d", *s.SpeakerID)
+				}
+				fmt.Printf(" %s\n", s.Text)
+			}
+		}
+	}
+	return nil
+}
+
+// formatMs 将毫秒格式化为 分:秒.毫秒
+func formatMs(ms int) string {
+	return fmt.Sprintf("%02d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+}
@@ -1,12 +1,33 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strings"
+
+	"github.com/lvdashuaibi/GPTUtils/models"
+)
+
+// DashScope 兼容模式接入地址
+const (
+	BaseURLChina         = "https://dashscope.aliyuncs.com/compatible-mode/v1"
+	BaseURLInternational = "https://dashscope-intl.aliyuncs.com/compatible-mode/v1"
+)
 
 // Config 配置结构
 type Config struct {
 	APIKey  string
 	BaseURL string
 	Model   string
+
+	// NativeBaseURL DashScope 原生接口地址（如 https://dashscope.aliyuncs.com/api/v1），
+	// 为空时由 BaseURL 推导
+	NativeBaseURL string
+
+	// Models 叠加在内置模型表之上的模型元数据，客户端据此校验请求参数
+	Models []models.Model
+
+	// Provider 服务商预设，决定鉴权方式、模型别名与协议差异，nil 时按 DashScope 处理
+	Provider *Provider
 }
 
 // DefaultConfig 返回默认配置
@@ -17,9 +38,10 @@ func DefaultConfig() *Config {
 	}
 
 	return &Config{
-		APIKey:  apiKey,
-		BaseURL: "https://dashscope.aliyuncs.com/compatible-mode/v1",
-		Model:   "qwen-plus",
+		APIKey:   apiKey,
+		BaseURL:  BaseURLChina,
+		Model:    "qwen-plus",
+		Provider: ProviderDashScope,
 	}
 }
 
@@ -40,3 +62,53 @@ func (c *Config) WithModel(model string) *Config {
 	c.Model = model
 	return c
 }
+
+// WithNativeBaseURL 设置 DashScope 原生接口地址
+func (c *Config) WithNativeBaseURL(nativeBaseURL string) *Config {
+	c.NativeBaseURL = nativeBaseURL
+	return c
+}
+
+// WithModels 添加模型元数据覆盖项，同名模型替换内置表中的定义
+func (c *Config) WithModels(ms ...models.Model) *Config {
+	c.Models = append(c.Models, ms...)
+	return c
+}
+
+// ModelRegistry 返回叠加了 Models 的模型注册表
+func (c *Config) ModelRegistry() *models.Registry {
+	if len(c.Models) == 0 {
+		return models.Default
+	}
+	return models.Default.With(c.Models...)
+}
+
+// DashScopeBaseURL 返回 DashScope 原生接口地址
+// 未显式设置时将兼容模式地址中的 /compatible-mode/v1 替换为 /api/v1
+func (c *Config) DashScopeBaseURL() string {
+	if c.NativeBaseURL != "" {
+		return strings.TrimRight(c.NativeBaseURL, "/")
+	}
+	base := strings.TrimRight(c.BaseURL, "/")
+	if i := strings.Index(base, "/compatible-mode"); i >= 0 {
+		return base[:i] + "/api/v1"
+	}
+	return base
+}
+
+// DashScopeWebSocketURL 返回 DashScope 流式语音等接口使用的 WebSocket 地址
+// 由原生接口地址推导，例如 https://dashscope.aliyuncs.com/api/v1 对应
+// wss://dashscope.aliyuncs.com/api-ws/v1/inference
+func (c *Config) DashScopeWebSocketURL() string {
+	base := c.DashScopeBaseURL()
+	switch {
+	case strings.HasPrefix(base, "https://"):
+		base = "wss://" + strings.TrimPrefix(base, "https://")
+	case strings.HasPrefix(base, "http://"):
+		base = "ws://" + strings.TrimPrefix(base, "http://")
+	}
+	if i := strings.Index(base, "/api/v1"); i >= 0 {
+		return base[:i] + "/api-ws/v1/inference"
+	}
+	return base + "/inference"
+}
@@ -0,0 +1,259 @@
+package config
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Provider OpenAI 兼容接口的服务商预设
+type Provider struct {
+	Name         string   // 名称，如 dashscope、openai
+	BaseURL      string   // 兼容接口地址
+	BaseURLEnv   string   // 设置后优先从该环境变量读取接口地址
+	APIKeyEnv    []string // 读取 API Key 的环境变量，按顺序查找
+	DefaultModel string   // 未指定模型时使用的模型
+
+	AuthHeader string            // 鉴权请求头，默认 Authorization
+	AuthPrefix string            // 鉴权值前缀，AuthHeader 为空时默认 "Bearer "
+	Headers    map[string]string // 附加到每个请求的请求头
+
+	Aliases map[string]string // 模型别名 -> 实际模型名
+	Quirks  Quirks
+}
+
+// Quirks 服务商与 OpenAI 协议的差异
+type Quirks struct {
+	APIKeyOptional bool     // 不需要 API Key，如本地部署的 Ollama、vLLM
+	NoStreamUsage  bool     // 不支持 stream_options.include_usage
+	DropParams     []string // 不支持的请求参数，发送前删除
+	MaxTemperature float64  // temperature 上限，超出时截断，0表示不限制
+	DashScope      bool     // 支持 DashScope 原生接口（重排、异步任务、语音等），为false时这些接口返回错误
+}
+
+// Drops 判断请求参数是否需要在发送前删除
+func (q Quirks) Drops(param string) bool {
+	for _, p := range q.DropParams {
+		if p == param {
+			return true
+		}
+	}
+	return false
+}
+
+// dashScopeOnlyParams 只有 DashScope 支持的扩展参数
+var dashScopeOnlyParams = []string{"enable_search", "enable_thinking"}
+
+// 内置服务商
+var (
+	ProviderDashScope = &Provider{
+		Name:         "dashscope",
+		BaseURL:      BaseURLChina,
+		APIKeyEnv:    []string{"API_KEY", "DASHSCOPE_API_KEY"},
+		DefaultModel: "qwen-plus",
+		Aliases: map[string]string{
+			"qwen":  "qwen-plus",
+			"coder": "qwen-coder-plus",
+		},
+		Quirks: Quirks{DashScope: true},
+	}
+
+	ProviderDashScopeIntl = &Provider{
+		Name:         "dashscope-intl",
+		BaseURL:      BaseURLInternational,
+		APIKeyEnv:    []string{"API_KEY", "DASHSCOPE_API_KEY"},
+		DefaultModel: "qwen-plus",
+		Aliases:      ProviderDashScope.Aliases,
+		Quirks:       Quirks{DashScope: true},
+	}
+
+	ProviderOpenAI = &Provider{
+		Name:         "openai",
+		BaseURL:      "https://api.openai.com/v1",
+		BaseURLEnv:   "OPENAI_BASE_URL",
+		APIKeyEnv:    []string{"OPENAI_API_KEY"},
+		DefaultModel: "gpt-4o-mini",
+		Aliases: map[string]string{
+			"gpt4": "gpt-4o",
+			"mini": "gpt-4o-mini",
+		},
+		Quirks: Quirks{DropParams: dashScopeOnlyParams},
+	}
+
+	ProviderDeepSeek = &Provider{
+		Name:         "deepseek",
+		BaseURL:      "https://api.deepseek.com/v1",
+		APIKeyEnv:    []string{"DEEPSEEK_API_KEY"},
+		DefaultModel: "deepseek-chat",
+		Aliases: map[string]string{
+			"v3": "deepseek-chat",
+			"r1": "deepseek-reasoner",
+		},
+		Quirks: Quirks{DropParams: dashScopeOnlyParams},
+	}
+
+	ProviderMoonshot = &Provider{
+		Name:         "moonshot",
+		BaseURL:      "https://api.moonshot.cn/v1",
+		APIKeyEnv:    []string{"MOONSHOT_API_KEY"},
+		DefaultModel: "moonshot-v1-8k",
+		Aliases: map[string]string{
+			"kimi": "moonshot-v1-8k",
+		},
+		// temperature 取值范围为 [0, 1]
+		Quirks: Quirks{DropParams: dashScopeOnlyParams, MaxTemperature: 1},
+	}
+
+	ProviderOllama = &Provider{
+		Name:         "ollama",
+		BaseURL:      "http://localhost:11434/v1",
+		BaseURLEnv:   "OLLAMA_BASE_URL",
+		APIKeyEnv:    []string{"OLLAMA_API_KEY"},
+		DefaultModel: "qwen2.5",
+		Quirks: Quirks{
+			APIKeyOptional: true,
+			DropParams:     dashScopeOnlyParams,
+		},
+	}
+
+	ProviderVLLM = &Provider{
+		Name:       "vllm",
+		BaseURL:    "http://localhost:8000/v1",
+		BaseURLEnv: "VLLM_BASE_URL",
+		APIKeyEnv:  []string{"VLLM_API_KEY"},
+		Quirks: Quirks{
+			APIKeyOptional: true,
+			DropParams:     dashScopeOnlyParams,
+		},
+	}
+)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]*Provider{}
+)
+
+func init() {
+	for _, p := range []*Provider{
+		ProviderDashScope, ProviderDashScopeIntl, ProviderOpenAI,
+		ProviderDeepSeek, ProviderMoonshot, ProviderOllama, ProviderVLLM,
+	} {
+		RegisterProvider(p)
+	}
+}
+
+// RegisterProvider 注册服务商，同名服务商被替换
+func RegisterProvider(p *Provider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[strings.ToLower(p.Name)] = p
+}
+
+// LookupProvider 按名称查找服务商，不区分大小写
+func LookupProvider(name string) (*Provider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	p, ok := providers[strings.ToLower(name)]
+	return p, ok
+}
+
+// Providers 返回按名称排序的全部服务商
+func Providers() []*Provider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	list := make([]*Provider, 0, len(providers))
+	for _, p := range providers {
+		list = append(list, p)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// ProviderConfig 按服务商预设创建配置，API Key 与接口地址从环境变量读取
+func ProviderConfig(name string) (*Config, error) {
+	p, ok := LookupProvider(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown provider %q", name)
+	}
+
+	var apiKey string
+	for _, env := range p.APIKeyEnv {
+		if apiKey = os.Getenv(env); apiKey != "" {
+			break
+		}
+	}
+	if apiKey == "" && !p.Quirks.APIKeyOptional {
+		return nil, fmt.Errorf("provider %s: API key not set, set one of %s", p.Name, strings.Join(p.APIKeyEnv, ", "))
+	}
+
+	baseURL := p.BaseURL
+	if p.BaseURLEnv != "" {
+		if v := os.Getenv(p.BaseURLEnv); v != "" {
+			baseURL = v
+		}
+	}
+
+	return &Config{
+		APIKey:   apiKey,
+		BaseURL:  baseURL,
+		Model:    p.DefaultModel,
+		Provider: p,
+	}, nil
+}
+
+// WithProvider 设置服务商，同时使用其接口地址与默认模型
+func (c *Config) WithProvider(p *Provider) *Config {
+	c.Provider = p
+	c.BaseURL = p.BaseURL
+	if p.DefaultModel != "" {
+		c.Model = p.DefaultModel
+	}
+	return c
+}
+
+// ProviderQuirks 返回服务商的协议差异，未设置服务商时按 DashScope 处理
+func (c *Config) ProviderQuirks() Quirks {
+	if c.Provider == nil {
+		return ProviderDashScope.Quirks
+	}
+	return c.Provider.Quirks
+}
+
+// ResolveModel 将模型别名解析为实际模型名，不是别名时原样返回
+func (c *Config) ResolveModel(model string) string {
+	if c.Provider == nil {
+		return model
+	}
+	if actual, ok := c.Provider.Aliases[model]; ok {
+		return actual
+	}
+	return model
+}
+
+// CheckDashScope 检查服务商是否支持 DashScope 原生接口（重排、异步任务、语音、原生文本生成等）
+func (c *Config) CheckDashScope() error {
+	if c.ProviderQuirks().DashScope {
+		return nil
+	}
+	return fmt.Errorf("provider %s does not support DashScope native APIs", c.Provider.Name)
+}
+
+// SetHeaders 设置鉴权与服务商附加的请求头，API Key 为空时不设置鉴权头
+func (c *Config) SetHeaders(h http.Header) {
+	header, prefix := "Authorization", "Bearer "
+	if p := c.Provider; p != nil {
+		if p.AuthHeader != "" {
+			header, prefix = p.AuthHeader, p.AuthPrefix
+		}
+		for k, v := range p.Headers {
+			h.Set(k, v)
+		}
+	}
+	if c.APIKey != "" {
+		h.Set(header, prefix+c.APIKey)
+	}
+}
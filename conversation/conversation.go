@@ -0,0 +1,282 @@
+// Package conversation 管理多轮对话历史
+//
+// Conversation 以树的形式保存对话：重新生成回答或编辑之前的用户消息会创建新的分支，
+// 原有分支仍然保留，可以随时切换。当前分支（从根到当前叶子节点的路径）即对话历史，
+// 每次请求前由记忆策略（Strategy）决定实际发送哪些消息：
+// 保留最近N轮、按token预算滑动窗口，或将较早的对话滚动压缩为摘要。
+package conversation
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// Client 对话使用的聊天客户端，即 client.ChatClient
+type Client = client.ChatClient
+
+// Strategy 记忆策略，从完整历史中选出本次请求要发送的消息
+// system 为系统提示词消息（未设置时为空），history 为不含系统提示词的完整历史，
+// 返回的消息列表应包含 system
+type Strategy interface {
+	Select(ctx context.Context, system []client.Message, history []client.Message) ([]client.Message, error)
+}
+
+// usageStreamer 能返回流式调用token使用情况的客户端，例如 *client.HTTPClient
+type usageStreamer interface {
+	ChatStreamWithUsage(ctx context.Context, req client.ChatRequest, handler client.StreamHandler) (*client.Usage, error)
+}
+
+// resetter 有内部状态的策略在清空历史时会被重置
+type resetter interface {
+	Reset()
+}
+
+// Conversation 多轮对话，并发安全
+type Conversation struct {
+	mu       sync.Mutex
+	client   Client
+	system   string
+	tree     tree
+	strategy Strategy
+	template client.ChatRequest
+	usage    client.Usage
+	created  time.Time
+}
+
+// New 创建对话，system 为空时不发送系统提示词
+// 默认不裁剪历史
+func New(c Client, system string) *Conversation {
+	return &Conversation{
+		client:  c,
+		system:  system,
+		tree:    newTree(),
+		created: time.Now(),
+	}
+}
+
+// WithStrategy 设置记忆策略，nil表示发送完整历史
+func (cv *Conversation) WithStrategy(s Strategy) *Conversation {
+	cv.strategy = s
+	return cv
+}
+
+// WithRequest 设置请求模板（模型、温度等），模板中的 Messages 会被忽略
+func (cv *Conversation) WithRequest(req client.ChatRequest) *Conversation {
+	cv.template = req
+	return cv
+}
+
+// System 返回系统提示词
+func (cv *Conversation) System() string {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return cv.system
+}
+
+// SetSystem 修改系统提示词
+func (cv *Conversation) SetSystem(system string) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	cv.system = system
+}
+
+// History 返回当前分支的历史副本，不含系统提示词
+func (cv *Conversation) History() []client.Message {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return cv.tree.history()
+}
+
+// Append 在当前分支末尾追加消息
+func (cv *Conversation) Append(msgs ...client.Message) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	for _, msg := range msgs {
+		cv.tree.add(msg)
+	}
+}
+
+// Usage 返回累计的token使用情况
+func (cv *Conversation) Usage() client.Usage {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return cv.usage
+}
+
+// Reset 清空所有分支和累计用量，保留系统提示词
+func (cv *Conversation) Reset() {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	cv.tree = newTree()
+	cv.usage = client.Usage{}
+	if r, ok := cv.strategy.(resetter); ok {
+		r.Reset()
+	}
+}
+
+// Messages 返回经过记忆策略处理、实际会发送的消息
+func (cv *Conversation) Messages(ctx context.Context) ([]client.Message, error) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return cv.messages(ctx)
+}
+
+func (cv *Conversation) messages(ctx context.Context) ([]client.Message, error) {
+	var system []client.Message
+	if cv.system != "" {
+		system = []client.Message{{Role: "system", Content: cv.system}}
+	}
+	history := cv.tree.history()
+
+	if cv.strategy == nil {
+		return append(system, history...), nil
+	}
+	return cv.strategy.Select(ctx, system, history)
+}
+
+// Send 发送用户消息并将回复加入历史，请求失败时历史不变
+func (cv *Conversation) Send(ctx context.Context, content string) (*client.ChatResponse, error) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	cv.tree.add(client.Message{Role: "user", Content: content})
+	resp, err := cv.complete(ctx)
+	if err != nil {
+		cv.tree.pop()
+		return nil, err
+	}
+	return resp, nil
+}
+
+// SendStream 流式发送用户消息并将完整回复加入历史，返回完整回复
+// 请求失败时历史不变
+func (cv *Conversation) SendStream(ctx context.Context, content string, handler client.StreamHandler) (string, error) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	cv.tree.add(client.Message{Role: "user", Content: content})
+	reply, err := cv.completeStream(ctx, handler)
+	if err != nil {
+		cv.tree.pop()
+		return "", err
+	}
+	return reply, nil
+}
+
+// Regenerate 为当前分支最后一条用户消息重新生成回答，新回答作为新分支，原回答仍然保留
+// 请求失败时历史不变
+func (cv *Conversation) Regenerate(ctx context.Context) (*client.ChatResponse, error) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	leaf, err := cv.tree.rewind()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := cv.complete(ctx)
+	if err != nil {
+		cv.tree.checkout(leaf)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// RegenerateStream 流式重新生成回答，见 Regenerate
+func (cv *Conversation) RegenerateStream(ctx context.Context, handler client.StreamHandler) (string, error) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	leaf, err := cv.tree.rewind()
+	if err != nil {
+		return "", err
+	}
+	reply, err := cv.completeStream(ctx, handler)
+	if err != nil {
+		cv.tree.checkout(leaf)
+		return "", err
+	}
+	return reply, nil
+}
+
+// complete 对当前分支发送请求并追加回答
+func (cv *Conversation) complete(ctx context.Context) (*client.ChatResponse, error) {
+	req, err := cv.request(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := cv.client.Chat(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	reply := ""
+	if len(resp.Choices) > 0 {
+		reply = resp.Choices[0].Message.Content
+	}
+	cv.tree.add(client.Message{Role: "assistant", Content: reply})
+	cv.addUsage(&resp.Usage)
+	return resp, nil
+}
+
+// completeStream 对当前分支发送流式请求并追加完整回答
+func (cv *Conversation) completeStream(ctx context.Context, handler client.StreamHandler) (string, error) {
+	req, err := cv.request(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var reply strings.Builder
+	collect := func(chunk string) error {
+		reply.WriteString(chunk)
+		if handler != nil {
+			return handler(chunk)
+		}
+		return nil
+	}
+
+	var usage *client.Usage
+	if us, ok := cv.client.(usageStreamer); ok {
+		usage, err = us.ChatStreamWithUsage(ctx, req, collect)
+	} else {
+		err = cv.client.ChatStream(ctx, req, collect)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	cv.tree.add(client.Message{Role: "assistant", Content: reply.String()})
+	cv.addUsage(usage)
+	return reply.String(), nil
+}
+
+func (cv *Conversation) addUsage(u *client.Usage) {
+	if u == nil {
+		return
+	}
+	cv.usage.PromptTokens += u.PromptTokens
+	cv.usage.CompletionTokens += u.CompletionTokens
+	cv.usage.TotalTokens += u.TotalTokens
+	if cached := u.CachedTokens(); cached > 0 {
+		if cv.usage.PromptTokensDetails == nil {
+			cv.usage.PromptTokensDetails = &client.PromptTokensDetails{}
+		}
+		cv.usage.PromptTokensDetails.CachedTokens += cached
+	}
+}
+
+// request 按模板和当前分支构造请求
+func (cv *Conversation) request(ctx context.Context) (client.ChatRequest, error) {
+	messages, err := cv.messages(ctx)
+	if err != nil {
+		return client.ChatRequest{}, err
+	}
+
+	req := cv.template
+	req.Messages = messages
+	return req, nil
+}
@@ -0,0 +1,120 @@
+package conversation
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// FileStore 每个会话一个JSON文件的会话存储
+type FileStore struct {
+	dir string
+}
+
+// NewFileStore 创建会话存储，目录不存在时自动创建
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// Close 实现 io.Closer 接口，文件存储不持有打开的资源，总是返回nil
+func (fs *FileStore) Close() error {
+	return nil
+}
+
+// Save 实现 SessionStore 接口，先写临时文件再重命名，避免写入中断损坏会话
+func (fs *FileStore) Save(ctx context.Context, s *Session) error {
+	path, err := fs.path(s.Name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load 实现 SessionStore 接口
+func (fs *FileStore) Load(ctx context.Context, name string) (*Session, error) {
+	path, err := fs.path(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var s Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("decode session %s: %w", name, err)
+	}
+	return &s, nil
+}
+
+// List 实现 SessionStore 接口，无法解析的文件会被跳过
+func (fs *FileStore) List(ctx context.Context) ([]SessionInfo, error) {
+	entries, err := os.ReadDir(fs.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var infos []SessionInfo
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(fs.dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var s Session
+		if json.Unmarshal(data, &s) != nil {
+			continue
+		}
+		infos = append(infos, s.Info())
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].UpdatedAt.After(infos[j].UpdatedAt) })
+	return infos, nil
+}
+
+// Delete 实现 SessionStore 接口
+func (fs *FileStore) Delete(ctx context.Context, name string) error {
+	path, err := fs.path(name)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrSessionNotFound
+	}
+	return err
+}
+
+// path 返回会话文件路径，会话名不能包含路径分隔符
+func (fs *FileStore) path(name string) (string, error) {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid session name: %q", name)
+	}
+	return filepath.Join(fs.dir, name+".json"), nil
+}
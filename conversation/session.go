@@ -0,0 +1,135 @@
+package conversation
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// ErrSessionNotFound 会话不存在
+var ErrSessionNotFound = errors.New("session not found")
+
+// Params 会话保存的请求参数
+type Params struct {
+	Temperature     *float64 `json:"temperature,omitempty"`
+	TopP            *float64 `json:"top_p,omitempty"`
+	MaxTokens       *int     `json:"max_tokens,omitempty"`
+	PresencePenalty *float64 `json:"presence_penalty,omitempty"`
+	Seed            *int64   `json:"seed,omitempty"`
+	Stop            []string `json:"stop,omitempty"`
+}
+
+// Session 持久化的会话
+type Session struct {
+	Name      string           `json:"name"`
+	Model     string           `json:"model,omitempty"`
+	System    string           `json:"system,omitempty"`
+	Messages  []client.Message `json:"messages"`        // 当前分支的消息
+	Nodes     []Node           `json:"nodes,omitempty"` // 完整对话树，为空时按 Messages 恢复
+	Leaf      int              `json:"leaf,omitempty"`  // 当前分支叶子节点ID
+	Params    Params           `json:"params"`
+	Usage     client.Usage     `json:"usage"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// SessionInfo 会话列表中的摘要信息
+type SessionInfo struct {
+	Name      string
+	Model     string
+	Messages  int
+	Usage     client.Usage
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Info 返回会话的摘要信息
+func (s *Session) Info() SessionInfo {
+	return SessionInfo{
+		Name:      s.Name,
+		Model:     s.Model,
+		Messages:  len(s.Messages),
+		Usage:     s.Usage,
+		CreatedAt: s.CreatedAt,
+		UpdatedAt: s.UpdatedAt,
+	}
+}
+
+// SessionStore 会话存储接口
+type SessionStore interface {
+	// Save 保存会话，同名会话会被覆盖
+	Save(ctx context.Context, s *Session) error
+	// Load 读取会话，不存在时返回 ErrSessionNotFound
+	Load(ctx context.Context, name string) (*Session, error)
+	// List 按更新时间倒序列出会话
+	List(ctx context.Context) ([]SessionInfo, error)
+	// Delete 删除会话，不存在时返回 ErrSessionNotFound
+	Delete(ctx context.Context, name string) error
+}
+
+// Session 导出当前对话为会话快照
+func (cv *Conversation) Session(name string) *Session {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	t := cv.template
+	return &Session{
+		Name:     name,
+		Model:    t.Model,
+		System:   cv.system,
+		Messages: cv.tree.history(),
+		Nodes:    cv.tree.export(),
+		Leaf:     cv.tree.leaf,
+		Params: Params{
+			Temperature:     t.Temperature,
+			TopP:            t.TopP,
+			MaxTokens:       t.MaxTokens,
+			PresencePenalty: t.PresencePenalty,
+			Seed:            t.Seed,
+			Stop:            t.Stop,
+		},
+		Usage:     cv.usage,
+		CreatedAt: cv.created,
+		UpdatedAt: time.Now(),
+	}
+}
+
+// Restore 从会话快照恢复对话树、模型和请求参数
+// 会话未记录模型时保留当前模型
+func (cv *Conversation) Restore(s *Session) error {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	t := newTree()
+	if len(s.Nodes) > 0 {
+		var err error
+		if t, err = loadTree(s.Nodes, s.Leaf); err != nil {
+			return err
+		}
+	} else {
+		for _, msg := range s.Messages {
+			t.add(msg)
+		}
+	}
+
+	cv.tree = t
+	cv.system = s.System
+	cv.usage = s.Usage
+	cv.created = s.CreatedAt
+	if s.Model != "" {
+		cv.template.Model = s.Model
+	}
+	cv.template.Temperature = s.Params.Temperature
+	cv.template.TopP = s.Params.TopP
+	cv.template.MaxTokens = s.Params.MaxTokens
+	cv.template.PresencePenalty = s.Params.PresencePenalty
+	cv.template.Seed = s.Params.Seed
+	cv.template.Stop = s.Params.Stop
+
+	if r, ok := cv.strategy.(resetter); ok {
+		r.Reset()
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+// Package sqlitestore 基于嵌入式 SQLite（纯Go驱动，无需cgo）的会话存储
+package sqlitestore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+	"github.com/lvdashuaibi/GPTUtils/conversation"
+
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS sessions (
+	name              TEXT PRIMARY KEY,
+	model             TEXT NOT NULL DEFAULT '',
+	system            TEXT NOT NULL DEFAULT '',
+	params            TEXT NOT NULL DEFAULT '{}',
+	prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+	completion_tokens INTEGER NOT NULL DEFAULT 0,
+	total_tokens      INTEGER NOT NULL DEFAULT 0,
+	cached_tokens     INTEGER NOT NULL DEFAULT 0,
+	tree              TEXT NOT NULL DEFAULT '',
+	created_at        INTEGER NOT NULL,
+	updated_at        INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS messages (
+	session TEXT NOT NULL REFERENCES sessions(name) ON DELETE CASCADE,
+	seq     INTEGER NOT NULL,
+	role    TEXT NOT NULL,
+	content TEXT NOT NULL,
+	PRIMARY KEY (session, seq)
+);`
+
+// Store SQLite 会话存储，实现了 conversation.SessionStore 接口
+type Store struct {
+	db *sql.DB
+}
+
+// Open 打开（不存在时创建）数据库文件
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path+"?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// treeState 序列化到 tree 列的对话树
+type treeState struct {
+	Nodes []conversation.Node `json:"nodes"`
+	Leaf  int                 `json:"leaf"`
+}
+
+// setCachedTokens 还原命中缓存的输入token数，为0时不设置明细
+func setCachedTokens(u *client.Usage, cached int) {
+	if cached > 0 {
+		u.PromptTokensDetails = &client.PromptTokensDetails{CachedTokens: cached}
+	}
+}
+
+// Close 关闭数据库
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Save 实现 conversation.SessionStore 接口
+func (s *Store) Save(ctx context.Context, sess *conversation.Session) error {
+	params, err := json.Marshal(sess.Params)
+	if err != nil {
+		return err
+	}
+	tree := ""
+	if len(sess.Nodes) > 0 {
+		data, err := json.Marshal(treeState{Nodes: sess.Nodes, Leaf: sess.Leaf})
+		if err != nil {
+			return err
+		}
+		tree = string(data)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO sessions (name, model, system, params, prompt_tokens, completion_tokens, total_tokens, cached_tokens, tree, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET
+			model = excluded.model,
+			system = excluded.system,
+			params = excluded.params,
+			prompt_tokens = excluded.prompt_tokens,
+			completion_tokens = excluded.completion_tokens,
+			total_tokens = excluded.total_tokens,
+			cached_tokens = excluded.cached_tokens,
+			tree = excluded.tree,
+			created_at = excluded.created_at,
+			updated_at = excluded.updated_at`,
+		sess.Name, sess.Model, sess.System, string(params),
+		sess.Usage.PromptTokens, sess.Usage.CompletionTokens, sess.Usage.TotalTokens, sess.Usage.CachedTokens(), tree,
+		sess.CreatedAt.UnixNano(), sess.UpdatedAt.UnixNano())
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE session = ?`, sess.Name); err != nil {
+		return err
+	}
+	for i, msg := range sess.Messages {
+		_, err := tx.ExecContext(ctx,
+			`INSERT INTO messages (session, seq, role, content) VALUES (?, ?, ?, ?)`,
+			sess.Name, i, msg.Role, msg.Content)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Load 实现 conversation.SessionStore 接口
+func (s *Store) Load(ctx context.Context, name string) (*conversation.Session, error) {
+	sess := &conversation.Session{Name: name}
+	var params, tree string
+	var cached int
+	var created, updated int64
+
+	err := s.db.QueryRowContext(ctx, `
+		SELECT model, system, params, prompt_tokens, completion_tokens, total_tokens, cached_tokens, tree, created_at, updated_at
+		FROM sessions WHERE name = ?`, name).
+		Scan(&sess.Model, &sess.System, &params,
+			&sess.Usage.PromptTokens, &sess.Usage.CompletionTokens, &sess.Usage.TotalTokens, &cached,
+			&tree, &created, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, conversation.ErrSessionNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(params), &sess.Params); err != nil {
+		return nil, err
+	}
+	if tree != "" {
+		var state treeState
+		if err := json.Unmarshal([]byte(tree), &state); err != nil {
+			return nil, err
+		}
+		sess.Nodes, sess.Leaf = state.Nodes, state.Leaf
+	}
+	setCachedTokens(&sess.Usage, cached)
+	sess.CreatedAt = time.Unix(0, created)
+	sess.UpdatedAt = time.Unix(0, updated)
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT role, content FROM messages WHERE session = ? ORDER BY seq`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var msg client.Message
+		if err := rows.Scan(&msg.Role, &msg.Content); err != nil {
+			return nil, err
+		}
+		sess.Messages = append(sess.Messages, msg)
+	}
+	return sess, rows.Err()
+}
+
+// List 实现 conversation.SessionStore 接口
+func (s *Store) List(ctx context.Context) ([]conversation.SessionInfo, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT s.name, s.model, s.prompt_tokens, s.completion_tokens, s.total_tokens, s.cached_tokens, s.created_at, s.updated_at,
+			(SELECT COUNT(*) FROM messages m WHERE m.session = s.name)
+		FROM sessions s ORDER BY s.updated_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var infos []conversation.SessionInfo
+	for rows.Next() {
+		var info conversation.SessionInfo
+		var cached int
+		var created, updated int64
+		err := rows.Scan(&info.Name, &info.Model,
+			&info.Usage.PromptTokens, &info.Usage.CompletionTokens, &info.Usage.TotalTokens, &cached,
+			&created, &updated, &info.Messages)
+		if err != nil {
+			return nil, err
+		}
+		setCachedTokens(&info.Usage, cached)
+		info.CreatedAt = time.Unix(0, created)
+		info.UpdatedAt = time.Unix(0, updated)
+		infos = append(infos, info)
+	}
+	return infos, rows.Err()
+}
+
+// Delete 实现 conversation.SessionStore 接口
+func (s *Store) Delete(ctx context.Context, name string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM sessions WHERE name = ?`, name)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return conversation.ErrSessionNotFound
+	}
+	return nil
+}
@@ -0,0 +1,271 @@
+package conversation
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"strings"
+	"sync"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+	"github.com/lvdashuaibi/GPTUtils/tokenizer"
+)
+
+// turnStarts 返回每轮对话的起始下标，一轮从用户消息开始
+// 历史开头不是用户消息时，开头部分也算作一轮
+func turnStarts(history []client.Message) []int {
+	var starts []int
+	for i, msg := range history {
+		if msg.Role == "user" || i == 0 {
+			starts = append(starts, i)
+		}
+	}
+	return starts
+}
+
+func withSystem(system, history []client.Message) []client.Message {
+	out := make([]client.Message, 0, len(system)+len(history))
+	out = append(out, system...)
+	return append(out, history...)
+}
+
+// LastN 只保留最近N轮对话
+type LastN struct {
+	N int
+}
+
+// NewLastN 创建保留最近n轮对话的策略
+func NewLastN(n int) *LastN {
+	return &LastN{N: n}
+}
+
+// Select 实现 Strategy 接口
+func (s *LastN) Select(ctx context.Context, system, history []client.Message) ([]client.Message, error) {
+	starts := turnStarts(history)
+	if s.N > 0 && len(starts) > s.N {
+		history = history[starts[len(starts)-s.N]:]
+	}
+	return withSystem(system, history), nil
+}
+
+// TokenWindow 按token预算从最早的对话开始整轮丢弃，始终保留系统提示词和最近一轮
+type TokenWindow struct {
+	MaxTokens int
+	Counter   tokenizer.Counter // 默认 tokenizer.Default()
+}
+
+// NewTokenWindow 创建token预算为 maxTokens 的滑动窗口策略
+func NewTokenWindow(maxTokens int) *TokenWindow {
+	return &TokenWindow{MaxTokens: maxTokens}
+}
+
+// WithCounter 设置token计数器
+func (s *TokenWindow) WithCounter(counter tokenizer.Counter) *TokenWindow {
+	s.Counter = counter
+	return s
+}
+
+// Select 实现 Strategy 接口
+func (s *TokenWindow) Select(ctx context.Context, system, history []client.Message) ([]client.Message, error) {
+	counter := s.Counter
+	if counter == nil {
+		counter = tokenizer.Default()
+	}
+
+	starts := turnStarts(history)
+	for i := 0; i < len(starts); i++ {
+		msgs := withSystem(system, history[starts[i]:])
+		if tokenizer.CountMessages(counter, msgs) <= s.MaxTokens || i == len(starts)-1 {
+			return msgs, nil
+		}
+	}
+	return withSystem(system, history), nil
+}
+
+// defaultSummaryPrompt 默认的摘要提示词
+const defaultSummaryPrompt = "请将下面的对话压缩为简洁的摘要，保留用户提供的关键信息、偏好、" +
+	"已经得出的结论和尚未解决的问题。只输出摘要内容，不要添加额外说明。"
+
+// Summarizer 滚动摘要策略
+// 发送的消息超过token预算时，由模型将较早的对话连同已有摘要压缩为新的摘要，
+// 摘要附加在系统提示词之后，最近 KeepTurns 轮对话保持原文
+type Summarizer struct {
+	client    Client
+	model     string
+	maxTokens int
+	keepTurns int
+	counter   tokenizer.Counter
+	prompt    string
+
+	mu      sync.Mutex
+	summary string
+	covered int    // 已压缩进摘要的历史消息数
+	digest  uint64 // 已压缩消息的指纹，用于发现历史被编辑或切换了分支
+}
+
+// NewSummarizer 创建滚动摘要策略，使用 c 生成摘要
+func NewSummarizer(c Client, maxTokens int) *Summarizer {
+	return &Summarizer{
+		client:    c,
+		maxTokens: maxTokens,
+		keepTurns: 2,
+		prompt:    defaultSummaryPrompt,
+	}
+}
+
+// WithModel 设置生成摘要使用的模型，默认使用客户端的默认模型
+func (s *Summarizer) WithModel(model string) *Summarizer {
+	s.model = model
+	return s
+}
+
+// WithKeepTurns 设置保持原文的最近对话轮数，默认2
+func (s *Summarizer) WithKeepTurns(n int) *Summarizer {
+	s.keepTurns = n
+	return s
+}
+
+// WithCounter 设置token计数器，默认 tokenizer.Default()
+func (s *Summarizer) WithCounter(counter tokenizer.Counter) *Summarizer {
+	s.counter = counter
+	return s
+}
+
+// WithPrompt 设置生成摘要的提示词
+func (s *Summarizer) WithPrompt(prompt string) *Summarizer {
+	s.prompt = prompt
+	return s
+}
+
+// Summary 返回当前摘要
+func (s *Summarizer) Summary() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.summary
+}
+
+// Reset 清空摘要
+func (s *Summarizer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.summary = ""
+	s.covered = 0
+	s.digest = 0
+}
+
+// Select 实现 Strategy 接口
+func (s *Summarizer) Select(ctx context.Context, system, history []client.Message) ([]client.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// 历史被清空、编辑或切换分支后摘要不再对应
+	if s.covered > len(history) || digest(history[:s.covered]) != s.digest {
+		s.summary = ""
+		s.covered = 0
+		s.digest = 0
+	}
+
+	counter := s.counter
+	if counter == nil {
+		counter = tokenizer.Default()
+	}
+
+	recent := history[s.covered:]
+	msgs := s.build(system, recent)
+	if tokenizer.CountMessages(counter, msgs) <= s.maxTokens {
+		return msgs, nil
+	}
+
+	starts := turnStarts(recent)
+	keep := s.keepTurns
+	if keep < 1 {
+		keep = 1
+	}
+	if len(starts) <= keep {
+		return msgs, nil
+	}
+
+	cut := starts[len(starts)-keep]
+	summary, err := s.summarize(ctx, recent[:cut])
+	if err != nil {
+		return nil, fmt.Errorf("summarize history: %w", err)
+	}
+	s.summary = summary
+	s.covered += cut
+	s.digest = digest(history[:s.covered])
+
+	return s.build(system, history[s.covered:]), nil
+}
+
+// build 将摘要并入系统提示词
+func (s *Summarizer) build(system, recent []client.Message) []client.Message {
+	if s.summary == "" {
+		return withSystem(system, recent)
+	}
+
+	note := "以下是之前对话的摘要：\n" + s.summary
+	head := client.Message{Role: "system", Content: note}
+	if len(system) > 0 {
+		head.Content = system[0].Content + "\n\n" + note
+		system = system[1:]
+	}
+
+	out := make([]client.Message, 0, len(system)+len(recent)+1)
+	out = append(out, head)
+	out = append(out, system...)
+	return append(out, recent...)
+}
+
+// summarize 将已有摘要和较早的对话压缩为新的摘要
+func (s *Summarizer) summarize(ctx context.Context, old []client.Message) (string, error) {
+	var sb strings.Builder
+	if s.summary != "" {
+		sb.WriteString("已有摘要：\n")
+		sb.WriteString(s.summary)
+		sb.WriteString("\n\n后续对话：\n")
+	}
+	for _, msg := range old {
+		fmt.Fprintf(&sb, "%s: %s\n", roleName(msg.Role), msg.Content)
+	}
+
+	resp, err := s.client.Chat(ctx, client.ChatRequest{
+		Model: s.model,
+		Messages: []client.Message{
+			{Role: "system", Content: s.prompt},
+			{Role: "user", Content: sb.String()},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.Choices) == 0 {
+		return "", fmt.Errorf("empty summary response")
+	}
+	return strings.TrimSpace(resp.Choices[0].Message.Content), nil
+}
+
+// digest 计算消息列表的指纹，空列表为0
+func digest(msgs []client.Message) uint64 {
+	if len(msgs) == 0 {
+		return 0
+	}
+	h := fnv.New64a()
+	for _, msg := range msgs {
+		h.Write([]byte(msg.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(msg.Content))
+		h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+func roleName(role string) string {
+	switch role {
+	case "user":
+		return "用户"
+	case "assistant":
+		return "助手"
+	default:
+		return role
+	}
+}
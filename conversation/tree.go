@@ -0,0 +1,244 @@
+package conversation
+
+import (
+	"fmt"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// Node 对话树节点
+type Node struct {
+	ID       int            `json:"id"`
+	Parent   int            `json:"parent"` // 父节点ID，0表示对话的第一条消息
+	Message  client.Message `json:"message"`
+	Children []int          `json:"children,omitempty"`
+	Active   int            `json:"active,omitempty"` // 最近选中的子节点ID，切换分支时沿它延伸，0表示没有
+}
+
+// tree 对话树，nodes[0] 为虚拟根节点，节点ID即下标
+type tree struct {
+	nodes []Node
+	leaf  int
+}
+
+func newTree() tree {
+	return tree{nodes: []Node{{}}}
+}
+
+// add 在当前叶子节点下追加消息并将其设为叶子
+func (t *tree) add(msg client.Message) int {
+	id := len(t.nodes)
+	t.nodes = append(t.nodes, Node{ID: id, Parent: t.leaf, Message: msg})
+
+	parent := &t.nodes[t.leaf]
+	parent.Children = append(parent.Children, id)
+	parent.Active = id
+	t.leaf = id
+	return id
+}
+
+// pop 撤销最近一次 add，只能在 add 之后立即调用
+func (t *tree) pop() {
+	id := len(t.nodes) - 1
+	parent := &t.nodes[t.nodes[id].Parent]
+	parent.Children = parent.Children[:len(parent.Children)-1]
+	parent.Active = 0
+	if n := len(parent.Children); n > 0 {
+		parent.Active = parent.Children[n-1]
+	}
+
+	t.leaf = t.nodes[id].Parent
+	t.nodes = t.nodes[:id]
+}
+
+// path 返回从第一条消息到叶子节点的节点ID
+func (t *tree) path() []int {
+	var ids []int
+	for id := t.leaf; id != 0; id = t.nodes[id].Parent {
+		ids = append(ids, id)
+	}
+	for i, j := 0, len(ids)-1; i < j; i, j = i+1, j-1 {
+		ids[i], ids[j] = ids[j], ids[i]
+	}
+	return ids
+}
+
+// history 返回当前分支的消息
+func (t *tree) history() []client.Message {
+	ids := t.path()
+	msgs := make([]client.Message, len(ids))
+	for i, id := range ids {
+		msgs[i] = t.nodes[id].Message
+	}
+	return msgs
+}
+
+// checkout 将叶子设为 id，并更新路径上各节点的选中子节点
+func (t *tree) checkout(id int) {
+	t.leaf = id
+	for id != 0 {
+		parent := t.nodes[id].Parent
+		t.nodes[parent].Active = id
+		id = parent
+	}
+}
+
+// descend 从 id 沿选中的子节点延伸到叶子
+func (t *tree) descend(id int) int {
+	for t.nodes[id].Active != 0 {
+		id = t.nodes[id].Active
+	}
+	return id
+}
+
+// rewind 为重新生成回答回退到最后一条用户消息，返回原叶子节点
+func (t *tree) rewind() (int, error) {
+	prev := t.leaf
+	id := t.leaf
+	if id != 0 && t.nodes[id].Message.Role == "assistant" {
+		id = t.nodes[id].Parent
+	}
+	if id == 0 || t.nodes[id].Message.Role != "user" {
+		return prev, fmt.Errorf("no user message to regenerate")
+	}
+	t.leaf = id
+	return prev, nil
+}
+
+// at 返回当前分支第 index 条消息的节点ID
+func (t *tree) at(index int) (int, error) {
+	ids := t.path()
+	if index < 0 || index >= len(ids) {
+		return 0, fmt.Errorf("message index %d out of range [0, %d)", index, len(ids))
+	}
+	return ids[index], nil
+}
+
+// export 返回除虚拟根节点外所有节点的副本
+func (t *tree) export() []Node {
+	nodes := make([]Node, len(t.nodes)-1)
+	for i, n := range t.nodes[1:] {
+		n.Children = append([]int(nil), n.Children...)
+		nodes[i] = n
+	}
+	return nodes
+}
+
+// loadTree 从导出的节点恢复对话树
+func loadTree(nodes []Node, leaf int) (tree, error) {
+	t := newTree()
+	for i, n := range nodes {
+		if n.ID != i+1 {
+			return t, fmt.Errorf("invalid conversation tree: node %d has id %d", i+1, n.ID)
+		}
+		if n.Parent < 0 || n.Parent >= n.ID {
+			return t, fmt.Errorf("invalid conversation tree: node %d has parent %d", n.ID, n.Parent)
+		}
+	}
+	if leaf < 0 || leaf > len(nodes) {
+		return t, fmt.Errorf("invalid conversation tree: leaf %d", leaf)
+	}
+
+	// 子节点列表和选中状态按父节点关系重建，不信任导出的 Children
+	for _, n := range nodes {
+		t.nodes = append(t.nodes, Node{ID: n.ID, Parent: n.Parent, Message: n.Message})
+		parent := &t.nodes[n.Parent]
+		parent.Children = append(parent.Children, n.ID)
+	}
+	for _, n := range nodes {
+		if n.Active != 0 && t.nodes[n.Active].Parent == n.ID {
+			t.nodes[n.ID].Active = n.Active
+		}
+	}
+	t.checkout(leaf)
+	return t, nil
+}
+
+// Tree 返回对话树的所有节点（不含虚拟根节点），节点ID从1开始且与下标+1一致
+func (cv *Conversation) Tree() []Node {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return cv.tree.export()
+}
+
+// Leaf 返回当前分支叶子节点的ID，0表示空对话
+func (cv *Conversation) Leaf() int {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+	return cv.tree.leaf
+}
+
+// Checkout 切换到以指定节点结尾的分支，id 为0时切换到空对话
+func (cv *Conversation) Checkout(id int) error {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	if id < 0 || id >= len(cv.tree.nodes) {
+		return fmt.Errorf("node %d not found", id)
+	}
+	cv.tree.checkout(id)
+	return nil
+}
+
+// Edit 将当前分支第 index 条消息（从0开始，必须是用户消息）修改为 content，
+// 修改后的消息作为新分支成为叶子，原分支仍然保留；之后调用 Regenerate 生成回答
+func (cv *Conversation) Edit(index int, content string) error {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	id, err := cv.tree.at(index)
+	if err != nil {
+		return err
+	}
+	node := cv.tree.nodes[id]
+	if node.Message.Role != "user" {
+		return fmt.Errorf("message %d is a %s message, only user messages can be edited", index, node.Message.Role)
+	}
+
+	cv.tree.leaf = node.Parent
+	cv.tree.add(client.Message{Role: "user", Content: content})
+	return nil
+}
+
+// Alternatives 返回当前分支第 index 条消息的所有候选版本（同一父节点下的兄弟节点），
+// 以及当前分支使用的是第几个候选
+func (cv *Conversation) Alternatives(index int) ([]Node, int, error) {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	id, err := cv.tree.at(index)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	siblings := cv.tree.nodes[cv.tree.nodes[id].Parent].Children
+	nodes := make([]Node, len(siblings))
+	current := 0
+	for i, sid := range siblings {
+		nodes[i] = cv.tree.nodes[sid]
+		nodes[i].Children = append([]int(nil), nodes[i].Children...)
+		if sid == id {
+			current = i
+		}
+	}
+	return nodes, current, nil
+}
+
+// SwitchBranch 将当前分支第 index 条消息切换为第 alt 个候选版本，
+// 并沿各节点最近选中的子节点延伸到叶子
+func (cv *Conversation) SwitchBranch(index, alt int) error {
+	cv.mu.Lock()
+	defer cv.mu.Unlock()
+
+	id, err := cv.tree.at(index)
+	if err != nil {
+		return err
+	}
+
+	siblings := cv.tree.nodes[cv.tree.nodes[id].Parent].Children
+	if alt < 0 || alt >= len(siblings) {
+		return fmt.Errorf("alternative %d out of range [0, %d)", alt, len(siblings))
+	}
+	cv.tree.checkout(cv.tree.descend(siblings[alt]))
+	return nil
+}
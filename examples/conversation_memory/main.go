@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/lvdashuaibi/GPTUtils/client"
+	"github.com/lvdashuaibi/GPTUtils/config"
+	"github.com/lvdashuaibi/GPTUtils/conversation"
+	"log"
+)
+
+func main() {
+	// 创建配置
+	cfg := config.DefaultConfig()
+
+	// 创建HTTP客户端
+	c := client.NewHTTPClient(cfg)
+
+	ctx := context.Background()
+
+	// 超过4000 token时，将较早的对话压缩为摘要，最近2轮保持原文
+	conv := conversation.New(c, "你是一个友好的AI助手").
+		WithStrategy(conversation.NewSummarizer(c, 4000).WithKeepTurns(2))
+
+	questions := []string{
+		"我想学习Go语言",
+		"Go语言有哪些优势？",
+		"能给我一个简单的代码示例吗？",
+	}
+
+	for i, q := range questions {
+		response, err := conv.Send(ctx, q)
+		if err != nil {
+			log.Fatalf("第%d轮对话失败: %v", i+1, err)
+		}
+
+		if len(response.Choices) > 0 {
+			fmt.Printf("第%d轮 - AI: %s\n\n", i+1, response.Choices[0].Message.Content)
+		}
+	}
+
+	fmt.Printf("历史消息数: %d\n", len(conv.History()))
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/lvdashuaibi/GPTUtils/client"
+	"github.com/lvdashuaibi/GPTUtils/config"
+	"log"
+)
+
+func main() {
+	// 创建配置
+	cfg := config.DefaultConfig()
+
+	// 创建原生协议客户端，请求与响应类型与 HTTPClient 相同
+	c := client.NewNativeClient(cfg)
+
+	ctx := context.Background()
+
+	topK := 50
+	enableSearch := true
+	resp, err := c.Chat(ctx, client.ChatRequest{
+		Messages: []client.Message{
+			{Role: "user", Content: "杭州明天天气怎么样？"},
+		},
+		EnableSearch: &enableSearch,
+		// 兼容模式不支持的原生参数
+		DashScope: &client.DashScopeParams{
+			TopK: &topK,
+			SearchOptions: &client.NativeSearchOptions{
+				ForcedSearch: true,
+				EnableSource: true,
+			},
+		},
+	})
+	if err != nil {
+		log.Fatalf("请求失败: %v", err)
+	}
+
+	if len(resp.Choices) > 0 {
+		fmt.Println(resp.Choices[0].Message.Content)
+	}
+
+	// 搜索来源
+	if resp.SearchInfo != nil {
+		for _, r := range resp.SearchInfo.SearchResults {
+			fmt.Printf("[%d] %s %s\n", r.Index, r.Title, r.URL)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package loader
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// DirectoryLoader 目录加载器，遍历目录并按扩展名选择加载器
+type DirectoryLoader struct {
+	// Include 包含的文件模式（相对于目录，使用 / 分隔），支持 ** 匹配任意层级目录；为空表示全部
+	Include []string
+	// Exclude 排除的文件或目录模式，优先于 Include
+	Exclude []string
+	// Loaders 按扩展名选择的加载器，默认 DefaultLoaders()
+	Loaders map[string]Loader
+	// FailOnUnsupported 为true时遇到没有对应加载器的文件返回错误，默认跳过
+	FailOnUnsupported bool
+}
+
+// Load 实现 Loader 接口，path 为目录
+func (l *DirectoryLoader) Load(path string) ([]Document, error) {
+	loaders := l.Loaders
+	if loaders == nil {
+		loaders = DefaultLoaders()
+	}
+
+	var docs []Document
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(path, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if rel != "." && matchAny(l.Exclude, rel) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if matchAny(l.Exclude, rel) {
+			return nil
+		}
+		if len(l.Include) > 0 && !matchAny(l.Include, rel) {
+			return nil
+		}
+
+		loader, ok := loaders[strings.ToLower(filepath.Ext(p))]
+		if !ok {
+			if l.FailOnUnsupported {
+				return fmt.Errorf("no loader for file: %s", p)
+			}
+			return nil
+		}
+
+		loaded, err := loader.Load(p)
+		if err != nil {
+			return err
+		}
+		for i := range loaded {
+			if loaded[i].Metadata == nil {
+				loaded[i].Metadata = make(map[string]string)
+			}
+			loaded[i].Metadata["relative_path"] = rel
+		}
+		docs = append(docs, loaded...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// matchAny 判断路径是否匹配任一模式
+// 不含 / 的模式同时与文件名匹配，例如 "*.md" 匹配任意层级的 Markdown 文件
+func matchAny(patterns []string, rel string) bool {
+	base := rel
+	if i := strings.LastIndex(rel, "/"); i >= 0 {
+		base = rel[i+1:]
+	}
+
+	for _, pattern := range patterns {
+		if !strings.Contains(pattern, "/") {
+			if ok, _ := filepath.Match(pattern, base); ok {
+				return true
+			}
+		}
+		if matchGlob(strings.Split(pattern, "/"), strings.Split(rel, "/")) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob 逐段匹配路径，** 匹配零个或多个目录
+func matchGlob(pattern, parts []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(parts); i++ {
+				if matchGlob(pattern[1:], parts[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(parts) == 0 {
+			return false
+		}
+		if ok, _ := filepath.Match(pattern[0], parts[0]); !ok {
+			return false
+		}
+		pattern, parts = pattern[1:], parts[1:]
+	}
+	return len(parts) == 0
+}
@@ -0,0 +1,144 @@
+package loader
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// HTMLLoader HTML 加载器，提取可读正文
+// 忽略脚本、样式、导航等非正文元素，块级元素之间以换行分隔，<title> 写入 Metadata["title"]
+type HTMLLoader struct {
+	// SkipTags 额外忽略的标签名
+	SkipTags []string
+}
+
+// 默认忽略的标签
+var skipTags = map[string]bool{
+	"script": true, "style": true, "noscript": true, "template": true,
+	"nav": true, "footer": true, "header": true, "aside": true,
+	"svg": true, "iframe": true, "form": true, "head": true,
+}
+
+// 块级元素，前后插入换行
+var blockTags = map[string]bool{
+	"p": true, "div": true, "section": true, "article": true, "main": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"ul": true, "ol": true, "li": true, "table": true, "tr": true,
+	"blockquote": true, "pre": true, "br": true, "hr": true,
+	"dl": true, "dt": true, "dd": true, "figure": true, "figcaption": true,
+}
+
+// Load 实现 Loader 接口
+func (l *HTMLLoader) Load(path string) ([]Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	text, encoding, err := decodeText(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	root, err := html.Parse(strings.NewReader(text))
+	if err != nil {
+		return nil, fmt.Errorf("%s: parse html: %w", path, err)
+	}
+
+	skip := make(map[string]bool, len(skipTags)+len(l.SkipTags))
+	for tag := range skipTags {
+		skip[tag] = true
+	}
+	for _, tag := range l.SkipTags {
+		skip[strings.ToLower(tag)] = true
+	}
+
+	metadata := map[string]string{
+		"source":   path,
+		"encoding": encoding,
+	}
+	if title := findTitle(root); title != "" {
+		metadata["title"] = title
+	}
+
+	var sb strings.Builder
+	extractText(root, skip, &sb)
+
+	return []Document{{Content: cleanText(sb.String()), Metadata: metadata}}, nil
+}
+
+// findTitle 查找 <title> 内容
+func findTitle(n *html.Node) string {
+	if n.Type == html.ElementNode && n.Data == "title" && n.FirstChild != nil {
+		return strings.TrimSpace(n.FirstChild.Data)
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if title := findTitle(c); title != "" {
+			return title
+		}
+	}
+	return ""
+}
+
+// extractText 深度优先提取文本
+func extractText(n *html.Node, skip map[string]bool, sb *strings.Builder) {
+	switch n.Type {
+	case html.TextNode:
+		sb.WriteString(n.Data)
+		return
+	case html.ElementNode:
+		if skip[n.Data] {
+			return
+		}
+		if n.Data == "pre" {
+			sb.WriteString("\n")
+			sb.WriteString(nodeText(n))
+			sb.WriteString("\n")
+			return
+		}
+	case html.CommentNode:
+		return
+	}
+
+	block := n.Type == html.ElementNode && blockTags[n.Data]
+	if block {
+		sb.WriteString("\n")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		extractText(c, skip, sb)
+	}
+	if block {
+		sb.WriteString("\n")
+	}
+}
+
+// nodeText 原样拼接节点下的文本，用于保留 <pre> 的格式
+func nodeText(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var sb strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		sb.WriteString(nodeText(c))
+	}
+	return sb.String()
+}
+
+var (
+	inlineSpace = regexp.MustCompile(`[ \t\f\r\x{00a0}]+`)
+	blankLines  = regexp.MustCompile(`\n\s*\n+`)
+)
+
+// cleanText 合并多余空白，保留段落间的空行
+func cleanText(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(inlineSpace.ReplaceAllString(line, " "))
+	}
+	text = strings.Join(lines, "\n")
+	return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+}
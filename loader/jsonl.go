@@ -0,0 +1,126 @@
+package loader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// JSONLLoader JSONL 加载器，每行一个文档
+type JSONLLoader struct {
+	// ContentField 正文字段，支持以点分隔的嵌套路径，默认 "content"
+	ContentField string
+	// MetadataFields 元数据字段映射：JSON字段路径 -> 元数据键；为空时收集所有顶层的非正文字段
+	// source 与 line 由加载器写入，不会被记录中的字段覆盖
+	MetadataFields map[string]string
+	// SkipInvalid 为true时跳过无法解析或缺少正文的行，否则返回错误
+	SkipInvalid bool
+}
+
+// Load 实现 Loader 接口
+func (l *JSONLLoader) Load(path string) ([]Document, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	contentField := l.ContentField
+	if contentField == "" {
+		contentField = "content"
+	}
+	// 正文字段所在的顶层字段不收集到元数据中
+	contentKey, _, _ := strings.Cut(contentField, ".")
+
+	var docs []Document
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			if l.SkipInvalid {
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+
+		content, ok := lookup(record, contentField)
+		if !ok {
+			if l.SkipInvalid {
+				continue
+			}
+			return nil, fmt.Errorf("%s:%d: missing field %q", path, lineNo, contentField)
+		}
+
+		metadata := map[string]string{
+			"source": path,
+			"line":   strconv.Itoa(lineNo),
+		}
+		if len(l.MetadataFields) > 0 {
+			for field, key := range l.MetadataFields {
+				if reservedMetadata(key) {
+					continue
+				}
+				if v, ok := lookup(record, field); ok {
+					metadata[key] = stringify(v)
+				}
+			}
+		} else {
+			for k, v := range record {
+				if k != contentKey && !reservedMetadata(k) {
+					metadata[k] = stringify(v)
+				}
+			}
+		}
+
+		docs = append(docs, Document{Content: stringify(content), Metadata: metadata})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// reservedMetadata 判断是否为加载器写入的元数据键，记录中的同名字段不能覆盖
+func reservedMetadata(key string) bool {
+	return key == "source" || key == "line"
+}
+
+// lookup 按点分隔的路径查找字段
+func lookup(record map[string]interface{}, path string) (interface{}, bool) {
+	var cur interface{} = record
+	for _, key := range strings.Split(path, ".") {
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		cur, ok = m[key]
+		if !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// stringify 将JSON值转换为字符串，非字符串值保留JSON格式
+func stringify(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,106 @@
+// Package loader 将本地文件和目录加载为文档，作为检索增强流程的输入
+//
+// 支持 Markdown（含 front matter）、HTML、纯文本（自动识别 UTF-8/GBK）和 JSONL，
+// 每个文档的 Metadata["source"] 为来源文件路径。
+package loader
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// Document 加载得到的文档
+type Document struct {
+	Content  string
+	Metadata map[string]string
+}
+
+// Loader 文档加载器
+type Loader interface {
+	Load(path string) ([]Document, error)
+}
+
+// LoaderFunc 函数形式的加载器
+type LoaderFunc func(path string) ([]Document, error)
+
+// Load 实现 Loader 接口
+func (f LoaderFunc) Load(path string) ([]Document, error) {
+	return f(path)
+}
+
+// DefaultLoaders 按扩展名（小写，含点）选择的默认加载器
+func DefaultLoaders() map[string]Loader {
+	text := &TextLoader{}
+	markdown := &MarkdownLoader{}
+	html := &HTMLLoader{}
+	return map[string]Loader{
+		".txt":      text,
+		".text":     text,
+		".log":      text,
+		".csv":      text,
+		".md":       markdown,
+		".markdown": markdown,
+		".html":     html,
+		".htm":      html,
+		".jsonl":    &JSONLLoader{},
+	}
+}
+
+// LoadFile 根据扩展名选择默认加载器加载单个文件
+func LoadFile(path string) ([]Document, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	l, ok := DefaultLoaders()[ext]
+	if !ok {
+		return nil, fmt.Errorf("no loader for file extension %q: %s", ext, path)
+	}
+	return l.Load(path)
+}
+
+// TextLoader 纯文本加载器，自动识别 UTF-8（含BOM）和 GBK 编码
+type TextLoader struct{}
+
+// Load 实现 Loader 接口
+func (l *TextLoader) Load(path string) ([]Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, encoding, err := decodeText(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return []Document{{
+		Content: content,
+		Metadata: map[string]string{
+			"source":   path,
+			"encoding": encoding,
+		},
+	}}, nil
+}
+
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// decodeText 识别编码并解码为 UTF-8 字符串
+// 合法的 UTF-8 按 UTF-8 处理，否则按 GB18030（GBK 的超集）解码
+func decodeText(data []byte) (content, encoding string, err error) {
+	if bytes.HasPrefix(data, utf8BOM) {
+		return string(data[len(utf8BOM):]), "utf-8", nil
+	}
+	if utf8.Valid(data) {
+		return string(data), "utf-8", nil
+	}
+
+	decoded, err := simplifiedchinese.GB18030.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", "", fmt.Errorf("decode as gbk: %w", err)
+	}
+	return string(decoded), "gbk", nil
+}
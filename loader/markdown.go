@@ -0,0 +1,95 @@
+package loader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MarkdownLoader Markdown 加载器
+// 文件开头的 YAML front matter（以 --- 包围）会被解析到 Metadata 中并从正文移除，
+// 列表值以逗号连接，嵌套对象按JSON风格格式化
+type MarkdownLoader struct{}
+
+// Load 实现 Loader 接口
+func (l *MarkdownLoader) Load(path string) ([]Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	text, encoding, err := decodeText(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	metadata := map[string]string{
+		"source":   path,
+		"encoding": encoding,
+	}
+
+	body, frontMatter := splitFrontMatter(text)
+	if frontMatter != "" {
+		var fields map[string]interface{}
+		if err := yaml.Unmarshal([]byte(frontMatter), &fields); err != nil {
+			return nil, fmt.Errorf("%s: parse front matter: %w", path, err)
+		}
+		for k, v := range fields {
+			if k == "source" {
+				continue
+			}
+			metadata[k] = formatValue(v)
+		}
+	}
+
+	return []Document{{Content: body, Metadata: metadata}}, nil
+}
+
+// splitFrontMatter 拆分 front matter 和正文，没有 front matter 时返回原文
+func splitFrontMatter(text string) (body, frontMatter string) {
+	normalized := strings.ReplaceAll(text, "\r\n", "\n")
+	if !strings.HasPrefix(normalized, "---\n") {
+		return text, ""
+	}
+
+	rest := normalized[len("---\n"):]
+	end := strings.Index(rest, "\n---")
+	if end < 0 {
+		return text, ""
+	}
+
+	after := rest[end+len("\n---"):]
+	// 结束标记必须独占一行
+	if after != "" && after[0] != '\n' {
+		return text, ""
+	}
+
+	return strings.TrimLeft(after, "\n"), rest[:end]
+}
+
+// formatValue 将 front matter 的值格式化为字符串
+func formatValue(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, item := range val {
+			parts[i] = formatValue(item)
+		}
+		return strings.Join(parts, ",")
+	case map[string]interface{}:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(data)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Capability 模型能力，可按位组合
+type Capability uint
+
+const (
+	Tools      Capability = 1 << iota // 工具调用
+	Vision                            // 图片输入
+	JSONSchema                        // 结构化输出（response_format）
+	Thinking                          // 深度思考（enable_thinking）
+	Search                            // 联网搜索（enable_search）
+)
+
+var capabilityNames = []struct {
+	c    Capability
+	name string
+}{
+	{Tools, "tools"},
+	{Vision, "vision"},
+	{JSONSchema, "json_schema"},
+	{Thinking, "thinking"},
+	{Search, "search"},
+}
+
+// String 返回以逗号分隔的能力名称
+func (c Capability) String() string {
+	var names []string
+	for _, n := range capabilityNames {
+		if c&n.c != 0 {
+			names = append(names, n.name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// ParseCapability 解析以逗号分隔的能力名称
+func ParseCapability(s string) (Capability, error) {
+	var c Capability
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		found := false
+		for _, n := range capabilityNames {
+			if n.name == part {
+				c |= n.c
+				found = true
+				break
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown capability: %s", part)
+		}
+	}
+	return c, nil
+}
+
+// MarshalJSON 序列化为能力名称数组
+func (c Capability) MarshalJSON() ([]byte, error) {
+	names := []string{}
+	if c != 0 {
+		names = strings.Split(c.String(), ",")
+	}
+	return json.Marshal(names)
+}
+
+// UnmarshalJSON 从能力名称数组解析
+func (c *Capability) UnmarshalJSON(data []byte) error {
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return err
+	}
+	parsed, err := ParseCapability(strings.Join(names, ","))
+	if err != nil {
+		return err
+	}
+	*c = parsed
+	return nil
+}
+
+// Range 数值参数的取值范围
+type Range struct {
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	MinOpen bool    `json:"min_open,omitempty"` // 为true时不包含 Min
+	MaxOpen bool    `json:"max_open,omitempty"` // 为true时不包含 Max
+}
+
+// Contains 判断取值是否在范围内
+func (r Range) Contains(v float64) bool {
+	if v < r.Min || (r.MinOpen && v == r.Min) {
+		return false
+	}
+	if v > r.Max || (r.MaxOpen && v == r.Max) {
+		return false
+	}
+	return true
+}
+
+// String 返回区间表示，例如 [0, 2)
+func (r Range) String() string {
+	left, right := "[", "]"
+	if r.MinOpen {
+		left = "("
+	}
+	if r.MaxOpen {
+		right = ")"
+	}
+	return left + strconv.FormatFloat(r.Min, 'g', -1, 64) + ", " + strconv.FormatFloat(r.Max, 'g', -1, 64) + right
+}
+
+func (r Range) isZero() bool {
+	return r == Range{}
+}
+
+// ParamRanges 采样参数的取值范围
+type ParamRanges struct {
+	Temperature     Range `json:"temperature"`
+	TopP            Range `json:"top_p"`
+	PresencePenalty Range `json:"presence_penalty"`
+}
+
+// DefaultRanges 通义千问兼容接口的参数范围
+var DefaultRanges = ParamRanges{
+	Temperature:     Range{Min: 0, Max: 2, MaxOpen: true},
+	TopP:            Range{Min: 0, Max: 1, MinOpen: true},
+	PresencePenalty: Range{Min: -2, Max: 2},
+}
@@ -0,0 +1,234 @@
+// Package models 提供模型元数据注册表
+//
+// 内置常用通义千问模型的上下文长度、能力（工具调用、视觉、结构化输出、深度思考、联网搜索）
+// 和参数范围，可以通过 Register 补充或覆盖，也可以用 Registry.With 在不修改全局表的情况下叠加覆盖。
+// 带日期的快照版本（如 qwen-plus-2025-01-25）会按最长前缀匹配到对应的模型。
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Model 模型元数据
+type Model struct {
+	ID              string       `json:"id"`
+	ContextWindow   int          `json:"context_window"`              // 上下文总长度（输入+输出）
+	MaxInputTokens  int          `json:"max_input_tokens,omitempty"`  // 最大输入token数，0表示等于 ContextWindow
+	MaxOutputTokens int          `json:"max_output_tokens,omitempty"` // 最大输出token数
+	Capabilities    Capability   `json:"capabilities,omitempty"`
+	Ranges          *ParamRanges `json:"ranges,omitempty"` // 参数范围，nil表示使用 DefaultRanges
+}
+
+// InputLimit 返回最大输入token数
+func (m Model) InputLimit() int {
+	if m.MaxInputTokens > 0 {
+		return m.MaxInputTokens
+	}
+	return m.ContextWindow
+}
+
+// Supports 判断模型是否支持全部指定能力
+func (m Model) Supports(c Capability) bool {
+	return m.Capabilities&c == c
+}
+
+// ParamRanges 返回模型的参数范围，未设置的参数使用 DefaultRanges
+func (m Model) ParamRanges() ParamRanges {
+	r := DefaultRanges
+	if m.Ranges == nil {
+		return r
+	}
+	if !m.Ranges.Temperature.isZero() {
+		r.Temperature = m.Ranges.Temperature
+	}
+	if !m.Ranges.TopP.isZero() {
+		r.TopP = m.Ranges.TopP
+	}
+	if !m.Ranges.PresencePenalty.isZero() {
+		r.PresencePenalty = m.Ranges.PresencePenalty
+	}
+	return r
+}
+
+// builtin 内置模型表
+var builtin = []Model{
+	{ID: "qwen-max", ContextWindow: 32768, MaxInputTokens: 30720, MaxOutputTokens: 8192, Capabilities: Tools | JSONSchema | Search},
+	{ID: "qwen-max-latest", ContextWindow: 131072, MaxInputTokens: 129024, MaxOutputTokens: 8192, Capabilities: Tools | JSONSchema | Search},
+	{ID: "qwen-plus", ContextWindow: 131072, MaxInputTokens: 129024, MaxOutputTokens: 16384, Capabilities: Tools | JSONSchema | Search | Thinking},
+	{ID: "qwen-plus-latest", ContextWindow: 1000000, MaxInputTokens: 995904, MaxOutputTokens: 32768, Capabilities: Tools | JSONSchema | Search | Thinking},
+	{ID: "qwen-turbo", ContextWindow: 1000000, MaxInputTokens: 1000000, MaxOutputTokens: 16384, Capabilities: Tools | JSONSchema | Search | Thinking},
+	{ID: "qwen-turbo-latest", ContextWindow: 1000000, MaxInputTokens: 1000000, MaxOutputTokens: 16384, Capabilities: Tools | JSONSchema | Search | Thinking},
+	{ID: "qwen-long", ContextWindow: 10000000, MaxInputTokens: 10000000, MaxOutputTokens: 8192},
+	{ID: "qwen-vl-max", ContextWindow: 131072, MaxInputTokens: 129024, MaxOutputTokens: 8192, Capabilities: Vision | JSONSchema},
+	{ID: "qwen-vl-plus", ContextWindow: 131072, MaxInputTokens: 129024, MaxOutputTokens: 8192, Capabilities: Vision | JSONSchema},
+	{ID: "qwq-plus", ContextWindow: 131072, MaxInputTokens: 98304, MaxOutputTokens: 8192, Capabilities: Tools | Thinking},
+	{ID: "qwen3-235b-a22b", ContextWindow: 131072, MaxInputTokens: 129024, MaxOutputTokens: 16384, Capabilities: Tools | JSONSchema | Thinking},
+	{ID: "qwen3-32b", ContextWindow: 131072, MaxInputTokens: 129024, MaxOutputTokens: 16384, Capabilities: Tools | JSONSchema | Thinking},
+	{ID: "qwen2.5-72b-instruct", ContextWindow: 131072, MaxInputTokens: 129024, MaxOutputTokens: 8192, Capabilities: Tools | JSONSchema},
+	{ID: "deepseek-v3", ContextWindow: 65536, MaxInputTokens: 57344, MaxOutputTokens: 8192, Capabilities: Tools},
+	{ID: "deepseek-r1", ContextWindow: 65536, MaxInputTokens: 57344, MaxOutputTokens: 8192, Capabilities: Thinking},
+}
+
+// Registry 模型注册表，并发安全
+type Registry struct {
+	mu     sync.RWMutex
+	models map[string]Model
+	parent *Registry
+}
+
+// NewRegistry 创建注册表
+func NewRegistry(models ...Model) *Registry {
+	r := &Registry{models: make(map[string]Model)}
+	r.Register(models...)
+	return r
+}
+
+// Default 全局注册表，包含内置模型表
+var Default = NewRegistry(builtin...)
+
+// With 返回叠加了覆盖项的注册表，查找时覆盖项优先，r 本身不受影响
+func (r *Registry) With(overrides ...Model) *Registry {
+	child := NewRegistry(overrides...)
+	child.parent = r
+	return child
+}
+
+// Register 注册或覆盖模型元数据
+func (r *Registry) Register(models ...Model) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, m := range models {
+		r.models[m.ID] = m
+	}
+}
+
+// Lookup 查找模型，找不到精确匹配时按最长前缀匹配快照版本
+// 覆盖项的精确匹配和前缀匹配都优先于上层注册表
+func (r *Registry) Lookup(id string) (Model, bool) {
+	if m, ok := r.lookup(id); ok {
+		return m, true
+	}
+	if r.parent != nil {
+		return r.parent.Lookup(id)
+	}
+	return Model{}, false
+}
+
+func (r *Registry) lookup(id string) (Model, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if m, ok := r.models[id]; ok {
+		return m, true
+	}
+
+	var best Model
+	found := false
+	for key, m := range r.models {
+		if strings.HasPrefix(id, key+"-") && len(key) > len(best.ID) {
+			best, found = m, true
+		}
+	}
+	if found {
+		best.ID = id
+	}
+	return best, found
+}
+
+// List 按ID排序返回所有已注册的模型，覆盖项替换上层的同名模型
+func (r *Registry) List() []Model {
+	merged := make(map[string]Model)
+	for reg := r; reg != nil; reg = reg.parent {
+		reg.mu.RLock()
+		for id, m := range reg.models {
+			if _, ok := merged[id]; !ok {
+				merged[id] = m
+			}
+		}
+		reg.mu.RUnlock()
+	}
+
+	out := make([]Model, 0, len(merged))
+	for _, m := range merged {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// CheckFit 检查输入token数加上请求的输出token数是否在模型限制内
+// 未注册的模型不做检查
+func (r *Registry) CheckFit(id string, promptTokens, maxTokens int) error {
+	m, ok := r.Lookup(id)
+	if !ok {
+		return nil
+	}
+
+	limit := m.InputLimit()
+	if m.ContextWindow > 0 && m.ContextWindow-maxTokens < limit {
+		limit = m.ContextWindow - maxTokens
+	}
+	if promptTokens > limit {
+		return &ContextError{Model: id, PromptTokens: promptTokens, MaxTokens: maxTokens, Limit: limit}
+	}
+	return nil
+}
+
+// Register 在全局注册表中注册或覆盖模型元数据
+func Register(models ...Model) {
+	Default.Register(models...)
+}
+
+// Lookup 在全局注册表中查找模型
+func Lookup(id string) (Model, bool) {
+	return Default.Lookup(id)
+}
+
+// List 按ID排序返回全局注册表中的所有模型
+func List() []Model {
+	return Default.List()
+}
+
+// CheckFit 使用全局注册表检查上下文长度
+func CheckFit(id string, promptTokens, maxTokens int) error {
+	return Default.CheckFit(id, promptTokens, maxTokens)
+}
+
+// LoadFile 从JSON文件读取模型列表，可用于 Register 或 Registry.With
+func LoadFile(path string) ([]Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []Model
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("decode models file %s: %w", path, err)
+	}
+	for i, m := range list {
+		if m.ID == "" {
+			return nil, fmt.Errorf("models file %s: entry %d has no id", path, i)
+		}
+	}
+	return list, nil
+}
+
+// ContextError 请求超出模型上下文长度
+type ContextError struct {
+	Model        string
+	PromptTokens int
+	MaxTokens    int // 请求的输出token数
+	Limit        int // 允许的输入token数
+}
+
+// Error 实现 error 接口
+func (e *ContextError) Error() string {
+	return fmt.Sprintf("context too long for model %s: %d prompt tokens (+%d output) exceeds limit %d",
+		e.Model, e.PromptTokens, e.MaxTokens, e.Limit)
+}
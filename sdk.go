@@ -33,6 +33,14 @@ import (
 // HTTPClient 导出HTTP客户端类型
 type HTTPClient = client.HTTPClient
 
+// ChatClient 导出统一的聊天客户端接口
+// *HTTPClient、*client.NativeClient、*client.FallbackClient 直接实现该接口，
+// 基于 OpenAI SDK 的 *client.Client 通过 AsChatClient 适配
+type ChatClient = client.ChatClient
+
+// ChatClientFuncs 导出由函数实现的 ChatClient，便于在测试中注入假客户端
+type ChatClientFuncs = client.ChatClientFuncs
+
 // NewClient 创建新的通义千问客户端
 // 这是推荐的创建客户端的方式
 func NewClient(cfg *config.Config) *client.HTTPClient {
@@ -59,3 +67,22 @@ type ChatResponse = client.ChatResponse
 
 // StreamHandler 导出流式处理器类型
 type StreamHandler = client.StreamHandler
+
+// Usage 导出token使用情况类型
+type Usage = client.Usage
+
+// Choice 导出候选结果类型
+type Choice = client.Choice
+
+// NewChatClient 按服务商预设创建 ChatClient，provider 为空时使用 DashScope
+// API Key 从服务商对应的环境变量读取
+func NewChatClient(provider string) (ChatClient, error) {
+	if provider == "" {
+		provider = config.ProviderDashScope.Name
+	}
+	cfg, err := config.ProviderConfig(provider)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewHTTPClient(cfg), nil
+}
@@ -0,0 +1,141 @@
+package textsplitter
+
+import (
+	"strings"
+)
+
+// MarkdownSplitter Markdown 切分器
+// 先按标题划分章节，代码块内的内容只按行切分，不会在行中间断开；
+// 块的 Metadata["headings"] 记录所在章节的标题路径，例如 "安装 > 方式1"
+type MarkdownSplitter struct {
+	recursive *RecursiveSplitter
+}
+
+// NewMarkdown 创建 Markdown 切分器
+func NewMarkdown(opts Options) *MarkdownSplitter {
+	return &MarkdownSplitter{recursive: NewRecursive(opts)}
+}
+
+// section 同一标题下的内容
+type section struct {
+	span
+	headings []string
+	blocks   []block
+}
+
+// block 章节内的正文或代码块
+type block struct {
+	span
+	code bool
+}
+
+// Split 切分 Markdown 文本
+func (s *MarkdownSplitter) Split(text string) []Chunk {
+	var chunks []Chunk
+	for _, sec := range parseMarkdown(text) {
+		var pieces []span
+		for _, b := range sec.blocks {
+			if b.code {
+				pieces = append(pieces, s.recursive.pieces(text, b.span, []string{"\n", ""})...)
+			} else {
+				pieces = append(pieces, s.recursive.pieces(text, b.span, s.recursive.opts.Separators)...)
+			}
+		}
+
+		var metadata map[string]string
+		if len(sec.headings) > 0 {
+			metadata = map[string]string{"headings": strings.Join(sec.headings, " > ")}
+		}
+		chunks = append(chunks, s.recursive.merge(text, pieces, metadata)...)
+	}
+	return chunks
+}
+
+// parseMarkdown 按标题划分章节，并在章节内区分代码块
+func parseMarkdown(text string) []section {
+	var (
+		sections []section
+		path     []string // 按级别保存的当前标题
+		cur      = section{span: span{0, 0}}
+		blockAt  = 0
+		fence    string // 当前代码块的围栏，空表示不在代码块中
+	)
+
+	closeBlock := func(end int, code bool) {
+		if end > blockAt {
+			cur.blocks = append(cur.blocks, block{span: span{blockAt, end}, code: code})
+		}
+		blockAt = end
+	}
+
+	pos := 0
+	for pos < len(text) {
+		lineEnd := strings.IndexByte(text[pos:], '\n')
+		if lineEnd < 0 {
+			lineEnd = len(text)
+		} else {
+			lineEnd += pos + 1
+		}
+		line := strings.TrimSpace(text[pos:lineEnd])
+
+		switch {
+		case fence != "":
+			if strings.HasPrefix(line, fence) && strings.Trim(line, fence[:1]) == "" {
+				fence = ""
+				closeBlock(lineEnd, true)
+			}
+		case strings.HasPrefix(line, "```") || strings.HasPrefix(line, "~~~"):
+			closeBlock(pos, false)
+			fence = line[:3]
+		default:
+			if level, title := heading(line); level > 0 {
+				closeBlock(pos, false)
+				cur.end = pos
+				if len(cur.blocks) > 0 {
+					sections = append(sections, cur)
+				}
+
+				if len(path) >= level {
+					path = path[:level-1]
+				}
+				for len(path) < level-1 {
+					path = append(path, "")
+				}
+				path = append(path, title)
+
+				cur = section{span: span{pos, pos}, headings: nonEmpty(path)}
+			}
+		}
+
+		pos = lineEnd
+	}
+
+	closeBlock(len(text), fence != "")
+	cur.end = len(text)
+	if len(cur.blocks) > 0 {
+		sections = append(sections, cur)
+	}
+	return sections
+}
+
+// heading 解析 ATX 标题，返回级别和标题文本，不是标题时级别为0
+func heading(line string) (int, string) {
+	level := 0
+	for level < len(line) && line[level] == '#' {
+		level++
+	}
+	if level == 0 || level > 6 || (level < len(line) && line[level] != ' ' && line[level] != '\t') {
+		return 0, ""
+	}
+	return level, strings.TrimSpace(strings.TrimRight(line[level:], "#"))
+}
+
+func nonEmpty(items []string) []string {
+	out := make([]string, 0, len(items))
+	for _, item := range items {
+		if item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,211 @@
+// Package textsplitter 提供面向检索增强的文本切分
+//
+// 切分按分隔符优先级递归进行，默认分隔符兼顾中文句读（。！？；）和英文标点；
+// 块大小按token估算，相邻块可以重叠；每个块都记录在原文中的字节偏移，便于引用回溯。
+package textsplitter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// Chunk 切分得到的文本块
+type Chunk struct {
+	Content  string
+	Start    int               // 在原文中的起始字节偏移
+	End      int               // 在原文中的结束字节偏移（不含），Content == text[Start:End]
+	Metadata map[string]string // Markdown 切分时包含 "headings" 标题路径
+}
+
+// DefaultSeparators 默认分隔符，按优先级排列
+// 句末标点保留在前一段末尾，空字符串表示按字符切分
+var DefaultSeparators = []string{
+	"\n\n", "\n",
+	"。", "！", "？", "；", "…",
+	". ", "! ", "? ", "; ",
+	"，", "、", ", ",
+	" ", "",
+}
+
+// Options 切分选项
+type Options struct {
+	ChunkSize    int                   // 每块最大长度，默认512
+	ChunkOverlap int                   // 相邻块的重叠长度，默认64，必须小于 ChunkSize
+	LengthFunc   func(text string) int // 长度计算，默认 client.EstimateTokens 估算token数
+	Separators   []string              // 分隔符，默认 DefaultSeparators
+}
+
+func (o Options) withDefaults() Options {
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = 512
+	}
+	if o.ChunkOverlap < 0 {
+		o.ChunkOverlap = 0
+	} else if o.ChunkOverlap == 0 {
+		o.ChunkOverlap = 64
+	}
+	if o.ChunkOverlap >= o.ChunkSize {
+		o.ChunkOverlap = o.ChunkSize / 4
+	}
+	if o.LengthFunc == nil {
+		o.LengthFunc = client.EstimateTokens
+	}
+	if len(o.Separators) == 0 {
+		o.Separators = DefaultSeparators
+	}
+	return o
+}
+
+// Splitter 文本切分器
+type Splitter interface {
+	Split(text string) []Chunk
+}
+
+// RecursiveSplitter 递归字符切分器
+type RecursiveSplitter struct {
+	opts Options
+}
+
+// NewRecursive 创建递归字符切分器
+// ChunkOverlap 传负数表示不重叠
+func NewRecursive(opts Options) *RecursiveSplitter {
+	return &RecursiveSplitter{opts: opts.withDefaults()}
+}
+
+// Split 切分文本
+func (s *RecursiveSplitter) Split(text string) []Chunk {
+	pieces := s.pieces(text, span{0, len(text)}, s.opts.Separators)
+	return s.merge(text, pieces, nil)
+}
+
+// span 原文中的一段区间
+type span struct {
+	start, end int
+}
+
+// pieces 将区间递归切分为不超过块大小的片段（尽量不超过）
+func (s *RecursiveSplitter) pieces(text string, sp span, seps []string) []span {
+	if sp.start >= sp.end {
+		return nil
+	}
+	if s.opts.LengthFunc(text[sp.start:sp.end]) <= s.opts.ChunkSize {
+		return []span{sp}
+	}
+
+	segment := text[sp.start:sp.end]
+	for i, sep := range seps {
+		if sep == "" {
+			return s.splitRunes(text, sp)
+		}
+		if !strings.Contains(segment, sep) {
+			continue
+		}
+
+		var out []span
+		for _, part := range splitKeep(segment, sep) {
+			part.start += sp.start
+			part.end += sp.start
+			out = append(out, s.pieces(text, part, seps[i+1:])...)
+		}
+		return out
+	}
+
+	return s.splitRunes(text, sp)
+}
+
+// splitRunes 没有可用分隔符时按字符切分
+func (s *RecursiveSplitter) splitRunes(text string, sp span) []span {
+	var out []span
+	start := sp.start
+	for start < sp.end {
+		end := start
+		for end < sp.end {
+			_, size := utf8.DecodeRuneInString(text[end:])
+			if end > start && s.opts.LengthFunc(text[start:end+size]) > s.opts.ChunkSize {
+				break
+			}
+			end += size
+		}
+		out = append(out, span{start, end})
+		start = end
+	}
+	return out
+}
+
+// splitKeep 按分隔符切分，分隔符保留在前一段末尾
+func splitKeep(text, sep string) []span {
+	var out []span
+	start := 0
+	for {
+		idx := strings.Index(text[start:], sep)
+		if idx < 0 {
+			break
+		}
+		end := start + idx + len(sep)
+		out = append(out, span{start, end})
+		start = end
+	}
+	if start < len(text) {
+		out = append(out, span{start, len(text)})
+	}
+	return out
+}
+
+// merge 将相邻片段合并为不超过块大小的块，新块开头重叠上一块末尾的若干片段
+func (s *RecursiveSplitter) merge(text string, pieces []span, metadata map[string]string) []Chunk {
+	var chunks []Chunk
+	emit := func(first, last int) {
+		c := trimChunk(text, pieces[first].start, pieces[last].end)
+		if c.Content == "" {
+			return
+		}
+		if metadata != nil {
+			c.Metadata = make(map[string]string, len(metadata))
+			for k, v := range metadata {
+				c.Metadata[k] = v
+			}
+		}
+		chunks = append(chunks, c)
+	}
+
+	length := func(first, last int) int {
+		return s.opts.LengthFunc(text[pieces[first].start:pieces[last].end])
+	}
+
+	first := 0
+	for first < len(pieces) {
+		last := first
+		for last+1 < len(pieces) && length(first, last+1) <= s.opts.ChunkSize {
+			last++
+		}
+		emit(first, last)
+
+		if last+1 >= len(pieces) {
+			break
+		}
+
+		// 从块末尾向前回退，保留不超过重叠长度的片段作为下一块开头
+		next := last + 1
+		for next-1 > first && length(next-1, last) <= s.opts.ChunkOverlap &&
+			length(next-1, last+1) <= s.opts.ChunkSize {
+			next--
+		}
+		first = next
+	}
+
+	return chunks
+}
+
+// trimChunk 去掉首尾空白并相应调整偏移
+func trimChunk(text string, start, end int) Chunk {
+	segment := text[start:end]
+	trimmedLeft := strings.TrimLeftFunc(segment, unicode.IsSpace)
+	start += len(segment) - len(trimmedLeft)
+	trimmed := strings.TrimRightFunc(trimmedLeft, unicode.IsSpace)
+	end = start + len(trimmed)
+
+	return Chunk{Content: trimmed, Start: start, End: end}
+}
@@ -0,0 +1,200 @@
+package tokenizer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Qwen 对话模板使用的特殊token
+const (
+	EndOfText = "<|endoftext|>"
+	IMStart   = "<|im_start|>"
+	IMEnd     = "<|im_end|>"
+)
+
+// BPE 字节级BPE分词器，词表为 tiktoken 格式（每行 "base64(token) rank"）
+type BPE struct {
+	ranks   map[string]int
+	special map[string]int
+
+	cache sync.Map // 预分词片段 -> token数
+}
+
+// LoadFile 从本地文件加载 tiktoken 格式词表
+func LoadFile(path string) (*BPE, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return Load(f)
+}
+
+// Load 读取 tiktoken 格式词表
+// Qwen 的特殊token编号紧跟在普通词表之后
+func Load(r io.Reader) (*BPE, error) {
+	ranks := make(map[string]int, 152000)
+	scanner := bufio.NewScanner(r)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		fields := bytes.Fields(line)
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("vocab line %d: expected 2 fields", lineNo)
+		}
+		token, err := base64.StdEncoding.DecodeString(string(fields[0]))
+		if err != nil {
+			return nil, fmt.Errorf("vocab line %d: %w", lineNo, err)
+		}
+		rank, err := strconv.Atoi(string(fields[1]))
+		if err != nil {
+			return nil, fmt.Errorf("vocab line %d: %w", lineNo, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("vocab is empty")
+	}
+
+	next := len(ranks)
+	special := map[string]int{
+		EndOfText: next,
+		IMStart:   next + 1,
+		IMEnd:     next + 2,
+	}
+
+	return &BPE{ranks: ranks, special: special}, nil
+}
+
+// Encode 将文本编码为token编号，文本中的特殊token按特殊token编码
+func (b *BPE) Encode(text string) []int {
+	var ids []int
+	for _, part := range b.splitSpecial(text) {
+		if id, ok := b.special[part]; ok {
+			ids = append(ids, id)
+			continue
+		}
+		for _, piece := range pretokenize(part) {
+			ids = append(ids, b.encodePiece(piece)...)
+		}
+	}
+	return ids
+}
+
+// Count 返回文本的token数
+func (b *BPE) Count(text string) int {
+	n := 0
+	for _, part := range b.splitSpecial(text) {
+		if _, ok := b.special[part]; ok {
+			n++
+			continue
+		}
+		for _, piece := range pretokenize(part) {
+			if cached, ok := b.cache.Load(piece); ok {
+				n += cached.(int)
+				continue
+			}
+			count := len(b.encodePiece(piece))
+			// 只缓存较短的片段，避免长文本撑大缓存
+			if len(piece) <= 64 {
+				b.cache.Store(piece, count)
+			}
+			n += count
+		}
+	}
+	return n
+}
+
+// splitSpecial 将文本按特殊token切开，特殊token单独成段
+func (b *BPE) splitSpecial(text string) []string {
+	if !strings.Contains(text, "<|") {
+		return []string{text}
+	}
+
+	var parts []string
+	for text != "" {
+		idx, token := -1, ""
+		for s := range b.special {
+			if i := strings.Index(text, s); i >= 0 && (idx < 0 || i < idx) {
+				idx, token = i, s
+			}
+		}
+		if idx < 0 {
+			parts = append(parts, text)
+			break
+		}
+		if idx > 0 {
+			parts = append(parts, text[:idx])
+		}
+		parts = append(parts, token)
+		text = text[idx+len(token):]
+	}
+	return parts
+}
+
+// encodePiece 对单个预分词片段做BPE合并
+func (b *BPE) encodePiece(piece string) []int {
+	if rank, ok := b.ranks[piece]; ok {
+		return []int{rank}
+	}
+
+	// parts[i] 为第i个子串的起始字节偏移，最后一个元素为片段长度
+	parts := make([]int, len(piece)+1)
+	for i := range parts {
+		parts[i] = i
+	}
+
+	rankOf := func(i int) int {
+		if i+2 >= len(parts) {
+			return math.MaxInt
+		}
+		if r, ok := b.ranks[piece[parts[i]:parts[i+2]]]; ok {
+			return r
+		}
+		return math.MaxInt
+	}
+
+	for len(parts) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i < len(parts)-2; i++ {
+			if r := rankOf(i); r < bestRank {
+				best, bestRank = i, r
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+
+	ids := make([]int, 0, len(parts)-1)
+	for i := 0; i < len(parts)-1; i++ {
+		token := piece[parts[i]:parts[i+1]]
+		if rank, ok := b.ranks[token]; ok {
+			ids = append(ids, rank)
+		} else {
+			// 词表应覆盖全部单字节，缺失时按字节数计
+			for j := 0; j < len(token); j++ {
+				ids = append(ids, -1)
+			}
+		}
+	}
+	return ids
+}
@@ -0,0 +1,142 @@
+package tokenizer
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// pretokenize 按 Qwen 的预分词规则切分文本：
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+//
+// Go 的正则不支持前瞻断言，因此按各分支的顺序手工实现
+func pretokenize(text string) []string {
+	var pieces []string
+	for i := 0; i < len(text); {
+		n := matchAt(text, i)
+		pieces = append(pieces, text[i:i+n])
+		i += n
+	}
+	return pieces
+}
+
+// matchAt 返回从位置 i 开始匹配的片段长度，至少为一个字符
+func matchAt(text string, i int) int {
+	r, size := utf8.DecodeRuneInString(text[i:])
+
+	// (?i:'s|'t|'re|'ve|'m|'ll|'d)
+	if r == '\'' {
+		if n := contraction(text[i+size:]); n > 0 {
+			return size + n
+		}
+	}
+
+	// [^\r\n\p{L}\p{N}]?\p{L}+
+	if unicode.IsLetter(r) {
+		return size + letters(text[i+size:])
+	}
+	if r != '\r' && r != '\n' && !unicode.IsNumber(r) {
+		if n := letters(text[i+size:]); n > 0 {
+			return size + n
+		}
+	}
+
+	// \p{N}
+	if unicode.IsNumber(r) {
+		return size
+	}
+
+	// ?[^\s\p{L}\p{N}]+[\r\n]*
+	start := i
+	if r == ' ' {
+		start += size
+	}
+	if n := symbols(text[start:]); n > 0 {
+		end := start + n
+		for end < len(text) && (text[end] == '\r' || text[end] == '\n') {
+			end++
+		}
+		return end - i
+	}
+
+	// 剩余分支都以空白开头
+	end, lastNewline := i, -1
+	for end < len(text) {
+		c, s := utf8.DecodeRuneInString(text[end:])
+		if !unicode.IsSpace(c) {
+			break
+		}
+		if c == '\r' || c == '\n' {
+			lastNewline = end + s
+		}
+		end += s
+	}
+	if end == i {
+		return size
+	}
+
+	// \s*[\r\n]+
+	if lastNewline > 0 {
+		return lastNewline - i
+	}
+
+	// \s+(?!\S)：后面是非空白时回退一个空白字符
+	if end < len(text) {
+		_, last := utf8.DecodeLastRuneInString(text[i:end])
+		if end-last > i {
+			return end - last - i
+		}
+	}
+
+	// \s+
+	return end - i
+}
+
+// contraction 匹配英文缩写后缀（不区分大小写）
+func contraction(s string) int {
+	for _, suffix := range []string{"re", "ve", "ll", "s", "t", "m", "d"} {
+		if len(s) >= len(suffix) && equalFoldASCII(s[:len(suffix)], suffix) {
+			return len(suffix)
+		}
+	}
+	return 0
+}
+
+func equalFoldASCII(a, b string) bool {
+	for i := 0; i < len(a); i++ {
+		c := a[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// letters 返回开头连续字母的字节长度
+func letters(s string) int {
+	n := 0
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if !unicode.IsLetter(r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+// symbols 返回开头连续的非空白、非字母、非数字字符的字节长度
+func symbols(s string) int {
+	n := 0
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		if unicode.IsSpace(r) || unicode.IsLetter(r) || unicode.IsNumber(r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
@@ -0,0 +1,135 @@
+// Package tokenizer 提供本地token计数
+//
+// 使用 Qwen 的字节级BPE词表（tiktoken 格式，即官方的 qwen.tiktoken 文件）。
+// 词表按以下顺序查找：
+//
+//  1. 使用 qwen_vocab 构建标签时嵌入的 qwen.tiktoken
+//  2. 环境变量 QWEN_TOKENIZER_FILE 指定的文件
+//  3. ~/.cache/gptutils/qwen.tiktoken
+//
+// 都找不到时回退到 client.EstimateTokens 的估算，此时 DefaultBPE 返回错误，
+// 需要准确计数的调用方（如上下文长度检查）应先通过 DefaultBPE 确认词表已加载。
+//
+// qwen.tiktoken 来自 Qwen 官方模型仓库，例如
+// https://huggingface.co/Qwen/Qwen-7B/blob/main/qwen.tiktoken
+package tokenizer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+	"github.com/lvdashuaibi/GPTUtils/models"
+)
+
+// Counter token计数接口，*BPE 实现了该接口
+type Counter interface {
+	Count(text string) int
+}
+
+// CounterFunc 将函数适配为 Counter
+type CounterFunc func(text string) int
+
+// Count 实现 Counter 接口
+func (f CounterFunc) Count(text string) int {
+	return f(text)
+}
+
+// Estimator 基于字符数的估算计数器，没有词表时使用
+var Estimator Counter = CounterFunc(client.EstimateTokens)
+
+// ChatML 模板的固定开销：
+// 每条消息为 "<|im_start|>{role}\n{content}<|im_end|>\n"，
+// 回复以 "<|im_start|>assistant\n" 开头
+const (
+	tokensPerMessage = 4 // <|im_start|>、角色后的换行、<|im_end|>、结尾换行
+	tokensPerReply   = 3 // <|im_start|>assistant\n
+)
+
+var (
+	defaultOnce sync.Once
+	defaultBPE  *BPE
+	defaultErr  error
+)
+
+// DefaultBPE 返回默认的 Qwen 词表，没有加载到词表时返回错误
+func DefaultBPE() (*BPE, error) {
+	defaultOnce.Do(func() {
+		defaultBPE, defaultErr = loadDefault()
+		if defaultErr != nil {
+			defaultErr = fmt.Errorf("qwen vocab not loaded, token counts are estimates: %w", defaultErr)
+		}
+	})
+	return defaultBPE, defaultErr
+}
+
+// Default 返回默认计数器，优先使用 Qwen 词表，找不到时回退到 Estimator
+// 是否为精确计数可通过 DefaultBPE 的错误判断
+func Default() Counter {
+	if bpe, err := DefaultBPE(); err == nil {
+		return bpe
+	}
+	return Estimator
+}
+
+// loadDefault 按包文档中的顺序加载词表
+func loadDefault() (*BPE, error) {
+	if len(embeddedVocab) > 0 {
+		return Load(bytes.NewReader(embeddedVocab))
+	}
+
+	if path := os.Getenv("QWEN_TOKENIZER_FILE"); path != "" {
+		return LoadFile(path)
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return LoadFile(filepath.Join(home, ".cache", "gptutils", "qwen.tiktoken"))
+}
+
+// Count 使用默认计数器计算文本的token数
+func Count(text string) int {
+	return Default().Count(text)
+}
+
+// CountTokens 使用默认计数器计算消息列表的token数，包含模板开销
+// 没有词表时为估算值，见 DefaultBPE
+func CountTokens(messages []client.Message) int {
+	return CountMessages(Default(), messages)
+}
+
+// CountMessages 使用指定计数器计算消息列表的token数，包含模板开销
+func CountMessages(counter Counter, messages []client.Message) int {
+	n := tokensPerReply
+	for _, msg := range messages {
+		n += tokensPerMessage
+		n += counter.Count(msg.Role)
+		n += counter.Count(msg.Content)
+	}
+	return n
+}
+
+// CheckFit 检查请求是否在模型上下文长度内，超出时返回 *models.ContextError
+// req.Model 为空或未注册时不做检查，未设置 MaxTokens 时只检查输入长度。
+// 没有词表时按估算值检查，可能放过实际超长的请求，需要严格检查时使用 CheckFitExact
+func CheckFit(req client.ChatRequest) error {
+	maxTokens := 0
+	if req.MaxTokens != nil {
+		maxTokens = *req.MaxTokens
+	}
+
+	return models.CheckFit(req.Model, CountTokens(req.Messages), maxTokens)
+}
+
+// CheckFitExact 与 CheckFit 相同，但没有加载到 Qwen 词表时返回错误而不是按估算值检查
+func CheckFitExact(req client.ChatRequest) error {
+	if _, err := DefaultBPE(); err != nil {
+		return err
+	}
+	return CheckFit(req)
+}
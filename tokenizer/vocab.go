@@ -0,0 +1,6 @@
+//go:build !qwen_vocab
+
+package tokenizer
+
+// embeddedVocab 默认不嵌入词表，使用 qwen_vocab 构建标签可嵌入
+var embeddedVocab []byte
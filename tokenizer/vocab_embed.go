@@ -0,0 +1,13 @@
+//go:build qwen_vocab
+
+package tokenizer
+
+import _ "embed"
+
+// embeddedVocab 嵌入的 Qwen 词表
+// 词表体积较大，不随仓库提供。构建前从 Qwen 官方模型仓库下载 qwen.tiktoken 放到本目录：
+//
+//	curl -L -o tokenizer/qwen.tiktoken https://huggingface.co/Qwen/Qwen-7B/resolve/main/qwen.tiktoken
+//
+//go:embed qwen.tiktoken
+var embeddedVocab []byte
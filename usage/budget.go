@@ -0,0 +1,202 @@
+package usage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+	"github.com/lvdashuaibi/GPTUtils/tokenizer"
+)
+
+// ErrBudgetExceeded 请求会超出预算，可以用 errors.Is 判断
+var ErrBudgetExceeded = errors.New("budget exceeded")
+
+// Budget 用量预算
+// Model、Tenant、Session 为空表示不限该维度，例如只设置 Tenant 即为该租户所有模型、会话的总预算；
+// MaxTokens、MaxCNY、MaxUSD 为0表示不限制
+type Budget struct {
+	Name    string
+	Model   string
+	Tenant  string
+	Session string
+
+	MaxTokens int
+	MaxCNY    float64
+	MaxUSD    float64
+
+	// Soft 为true时超出预算只调用 Tracker.OnBudget，不拒绝请求
+	Soft bool
+}
+
+// matches 判断维度组合是否在预算范围内
+func (b Budget) matches(k Key) bool {
+	return (b.Model == "" || b.Model == k.Model) &&
+		(b.Tenant == "" || b.Tenant == k.Tenant) &&
+		(b.Session == "" || b.Session == k.Session)
+}
+
+// exceeded 判断用量是否超出预算
+func (b Budget) exceeded(r Record) bool {
+	return (b.MaxTokens > 0 && r.TotalTokens > b.MaxTokens) ||
+		(b.MaxCNY > 0 && r.Cost.CNY > b.MaxCNY) ||
+		(b.MaxUSD > 0 && r.Cost.USD > b.MaxUSD)
+}
+
+// BudgetError 请求会超出预算
+type BudgetError struct {
+	Budget   Budget
+	Spent    Record // 预算范围内已经产生的用量
+	Reserved Record // 预算范围内进行中的请求预占的用量
+	Estimate Record // 本次请求的预估用量
+}
+
+// Error 实现 error 接口
+func (e *BudgetError) Error() string {
+	name := e.Budget.Name
+	if name == "" {
+		var scope []string
+		for _, s := range []struct{ k, v string }{
+			{"model", e.Budget.Model}, {"tenant", e.Budget.Tenant}, {"session", e.Budget.Session},
+		} {
+			if s.v != "" {
+				scope = append(scope, s.k+"="+s.v)
+			}
+		}
+		name = strings.Join(scope, ",")
+		if name == "" {
+			name = "total"
+		}
+	}
+	msg := fmt.Sprintf("budget %s exceeded: spent %d tokens (%.4f CNY, %.4f USD)",
+		name, e.Spent.TotalTokens, e.Spent.Cost.CNY, e.Spent.Cost.USD)
+	if e.Reserved.Requests > 0 {
+		msg += fmt.Sprintf(", %d in-flight requests reserved %d tokens", e.Reserved.Requests, e.Reserved.TotalTokens)
+	}
+	return msg + fmt.Sprintf(", request needs about %d tokens", e.Estimate.TotalTokens)
+}
+
+// Is 使 errors.Is(err, ErrBudgetExceeded) 成立
+func (e *BudgetError) Is(target error) bool {
+	return target == ErrBudgetExceeded
+}
+
+// Estimate 预估请求的用量：输入按 tokenizer.CountTokens 计算，输出按 MaxTokens 计（未设置时为0）
+func (t *Tracker) Estimate(req client.ChatRequest) Record {
+	u := client.Usage{PromptTokens: tokenizer.CountTokens(req.Messages)}
+	if req.MaxTokens != nil {
+		u.CompletionTokens = *req.MaxTokens
+	}
+	u.TotalTokens = u.PromptTokens + u.CompletionTokens
+
+	return Record{
+		Requests:         1,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+		Cost:             t.prices.Cost(req.Model, u),
+	}
+}
+
+// Check 检查请求是否会超出预算，未超出硬预算时预占本次请求的预估用量
+// 已记录的用量与进行中请求的预占之和用于判断，避免并发请求同时通过检查后超出预算。
+// 超出硬预算时返回 *BudgetError 且不预占；超出软预算时只调用 OnBudget。
+// 请求结束后必须调用返回的 Reservation 的 Record 或 Release
+func (t *Tracker) Check(key Key, req client.ChatRequest) (*Reservation, error) {
+	estimate := t.Estimate(req)
+
+	t.mu.Lock()
+	var hard *BudgetError
+	var events []*BudgetError
+	for _, b := range t.budgets {
+		if !b.matches(key) {
+			continue
+		}
+		spent := t.sum(b.matches)
+		reserved := t.sumPending(b.matches)
+		if !b.exceeded(spent.Add(reserved).Add(estimate)) {
+			continue
+		}
+
+		err := &BudgetError{Budget: b, Spent: spent, Reserved: reserved, Estimate: estimate}
+		events = append(events, err)
+		if !b.Soft && hard == nil {
+			hard = err
+		}
+	}
+	if hard == nil {
+		t.pending[key] = t.pending[key].Add(estimate)
+	}
+	onBudget := t.OnBudget
+	t.mu.Unlock()
+
+	if onBudget != nil {
+		for _, e := range events {
+			onBudget(e)
+		}
+	}
+	if hard != nil {
+		return nil, hard
+	}
+	return &Reservation{tracker: t, key: key, estimate: estimate}, nil
+}
+
+func (t *Tracker) sumPending(match func(Key) bool) Record {
+	var total Record
+	for k, r := range t.pending {
+		if match(k) {
+			total = total.Add(r)
+		}
+	}
+	return total
+}
+
+// Reservation 一次请求预占的预算
+type Reservation struct {
+	tracker  *Tracker
+	key      Key
+	estimate Record
+	once     sync.Once
+}
+
+// Record 释放预占并记录实际用量，返回本次调用的费用；重复调用时不再记录
+func (r *Reservation) Record(u client.Usage) Cost {
+	var cost Cost
+	r.once.Do(func() {
+		t := r.tracker
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.release(r.key, r.estimate)
+		cost = t.record(r.key, u)
+	})
+	return cost
+}
+
+// Release 释放预占而不记录用量，用于请求失败或响应来自缓存
+func (r *Reservation) Release() {
+	r.once.Do(func() {
+		t := r.tracker
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.release(r.key, r.estimate)
+	})
+}
+
+// release 从预占中扣除一次请求的预估用量
+func (t *Tracker) release(key Key, estimate Record) {
+	p := t.pending[key]
+	p = Record{
+		Requests:         p.Requests - estimate.Requests,
+		PromptTokens:     p.PromptTokens - estimate.PromptTokens,
+		CompletionTokens: p.CompletionTokens - estimate.CompletionTokens,
+		CachedTokens:     p.CachedTokens - estimate.CachedTokens,
+		TotalTokens:      p.TotalTokens - estimate.TotalTokens,
+		Cost:             Cost{CNY: p.Cost.CNY - estimate.Cost.CNY, USD: p.Cost.USD - estimate.Cost.USD},
+	}
+	if p.Requests <= 0 {
+		delete(t.pending, key)
+		return
+	}
+	t.pending[key] = p
+}
@@ -0,0 +1,93 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync"
+	"testing"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+func TestBudgetReservesConcurrentRequests(t *testing.T) {
+	tracker := NewTracker(nil).WithBudget(Budget{MaxTokens: 250})
+	maxTokens := 100
+	req := client.ChatRequest{Model: "qwen-plus", MaxTokens: &maxTokens}
+
+	// 请求阻塞到全部检查结束，模拟并发的进行中请求
+	release := make(chan struct{})
+	chat := tracker.WrapChat(func(ctx context.Context, req client.ChatRequest) (*client.ChatResponse, error) {
+		<-release
+		return &client.ChatResponse{Usage: client.Usage{CompletionTokens: 100, TotalTokens: 100}}, nil
+	})
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		rejected int
+	)
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := chat(context.Background(), req)
+			if errors.Is(err, ErrBudgetExceeded) {
+				mu.Lock()
+				rejected++
+				mu.Unlock()
+			}
+		}()
+	}
+
+	// 两个请求预占200 tokens 后，其余请求被拒绝
+	for {
+		mu.Lock()
+		n := rejected
+		mu.Unlock()
+		if n == 3 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+
+	if got := tracker.Total(); got.Requests != 2 || got.TotalTokens != 200 {
+		t.Errorf("total = %+v", got)
+	}
+	if len(tracker.pending) != 0 {
+		t.Errorf("pending = %+v, want released", tracker.pending)
+	}
+}
+
+func TestBudgetReleasesOnErrorAndCache(t *testing.T) {
+	tracker := NewTracker(nil).WithBudget(Budget{MaxTokens: 150})
+	maxTokens := 100
+	req := client.ChatRequest{Model: "qwen-plus", MaxTokens: &maxTokens}
+
+	failed := tracker.WrapChat(func(context.Context, client.ChatRequest) (*client.ChatResponse, error) {
+		return nil, errors.New("boom")
+	})
+	cached := tracker.WrapChat(func(context.Context, client.ChatRequest) (*client.ChatResponse, error) {
+		resp := &client.ChatResponse{Usage: client.Usage{TotalTokens: 100}}
+		resp.Meta.Cached = true
+		return resp, nil
+	})
+
+	// 失败和缓存命中都不占用预算，之后的请求仍能通过
+	for i := 0; i < 3; i++ {
+		if _, err := failed(context.Background(), req); errors.Is(err, ErrBudgetExceeded) {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+		if _, err := cached(context.Background(), req); err != nil {
+			t.Fatalf("attempt %d: %v", i, err)
+		}
+	}
+	if got := tracker.Total(); got.Requests != 0 {
+		t.Errorf("total = %+v, want nothing recorded", got)
+	}
+	if len(tracker.pending) != 0 {
+		t.Errorf("pending = %+v, want released", tracker.pending)
+	}
+}
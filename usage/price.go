@@ -0,0 +1,127 @@
+package usage
+
+import (
+	"strings"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// Price 每百万token的价格
+type Price struct {
+	Input       float64 // 输入
+	CachedInput float64 // 命中上下文缓存的输入，0表示按 Input 计费
+	Output      float64 // 输出
+}
+
+// cost 计算一次调用的费用
+func (p Price) cost(u client.Usage) float64 {
+	cached := u.CachedTokens()
+	cachedPrice := p.CachedInput
+	if cachedPrice == 0 {
+		cachedPrice = p.Input
+	}
+	return (float64(u.PromptTokens-cached)*p.Input +
+		float64(cached)*cachedPrice +
+		float64(u.CompletionTokens)*p.Output) / 1e6
+}
+
+// ModelPrice 模型在各币种下的价格，某币种价格为零值表示未知
+type ModelPrice struct {
+	CNY Price // 人民币，中国站价格
+	USD Price // 美元，国际站价格
+}
+
+// Cost 费用
+type Cost struct {
+	CNY float64
+	USD float64
+}
+
+// Add 返回两项费用之和
+func (c Cost) Add(o Cost) Cost {
+	return Cost{CNY: c.CNY + o.CNY, USD: c.USD + o.USD}
+}
+
+// PriceTable 模型价格表，键为模型ID
+// 查找时带日期的快照版本按最长前缀匹配，例如 qwen-plus-2025-01-25 使用 qwen-plus 的价格
+type PriceTable map[string]ModelPrice
+
+// DefaultPrices 返回内置价格表的副本（阶梯计价的模型取最低一档），价格以官网为准
+func DefaultPrices() PriceTable {
+	t := make(PriceTable, len(defaultPrices))
+	for k, v := range defaultPrices {
+		t[k] = v
+	}
+	return t
+}
+
+var defaultPrices = PriceTable{
+	"qwen-max": {
+		CNY: Price{Input: 2.4, CachedInput: 0.96, Output: 9.6},
+		USD: Price{Input: 1.6, CachedInput: 0.64, Output: 6.4},
+	},
+	"qwen-plus": {
+		CNY: Price{Input: 0.8, CachedInput: 0.32, Output: 2},
+		USD: Price{Input: 0.4, CachedInput: 0.16, Output: 1.2},
+	},
+	"qwen-turbo": {
+		CNY: Price{Input: 0.3, CachedInput: 0.12, Output: 0.6},
+		USD: Price{Input: 0.05, CachedInput: 0.02, Output: 0.2},
+	},
+	"qwen-long": {
+		CNY: Price{Input: 0.5, Output: 2},
+	},
+	"qwen-vl-max": {
+		CNY: Price{Input: 3, Output: 9},
+		USD: Price{Input: 0.8, Output: 3.2},
+	},
+	"qwen-vl-plus": {
+		CNY: Price{Input: 1.5, Output: 4.5},
+		USD: Price{Input: 0.21, Output: 0.63},
+	},
+	"qwq-plus": {
+		CNY: Price{Input: 1.6, Output: 4},
+	},
+	"qwen3-235b-a22b": {
+		CNY: Price{Input: 2, Output: 8},
+		USD: Price{Input: 0.7, Output: 2.8},
+	},
+	"qwen3-32b": {
+		CNY: Price{Input: 2, Output: 8},
+		USD: Price{Input: 0.7, Output: 2.8},
+	},
+	"qwen2.5-72b-instruct": {
+		CNY: Price{Input: 4, Output: 12},
+		USD: Price{Input: 1.4, Output: 5.6},
+	},
+	"deepseek-v3": {
+		CNY: Price{Input: 2, Output: 8},
+	},
+	"deepseek-r1": {
+		CNY: Price{Input: 4, Output: 16},
+	},
+}
+
+// Lookup 查找模型价格，找不到精确匹配时按最长前缀匹配快照版本
+func (t PriceTable) Lookup(model string) (ModelPrice, bool) {
+	if p, ok := t[model]; ok {
+		return p, true
+	}
+
+	best, found := "", false
+	for key := range t {
+		if strings.HasPrefix(model, key+"-") && len(key) > len(best) {
+			best, found = key, true
+		}
+	}
+	return t[best], found
+}
+
+// Cost 计算一次调用的费用，未知模型费用为0
+func (t PriceTable) Cost(model string, u client.Usage) Cost {
+	p, ok := t.Lookup(model)
+	if !ok {
+		return Cost{}
+	}
+	return Cost{CNY: p.CNY.cost(u), USD: p.USD.cost(u)}
+}
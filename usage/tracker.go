@@ -0,0 +1,224 @@
+// Package usage 统计token用量和费用，并按预算限制请求
+//
+// Tracker 按模型、租户和会话汇总输入/输出/缓存token数，按价格表换算为人民币和美元费用。
+// 作为 client.Middleware 注册到 HTTPClient 后，流式和非流式调用都会自动记录，
+// 租户和会话通过 WithTenant、WithSession 写入 context。
+package usage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/lvdashuaibi/GPTUtils/client"
+)
+
+// Key 用量汇总维度
+type Key struct {
+	Model   string
+	Tenant  string
+	Session string
+}
+
+// Record 汇总的用量
+type Record struct {
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	CachedTokens     int
+	TotalTokens      int
+	Cost             Cost
+}
+
+// Add 返回两项用量之和
+func (r Record) Add(o Record) Record {
+	return Record{
+		Requests:         r.Requests + o.Requests,
+		PromptTokens:     r.PromptTokens + o.PromptTokens,
+		CompletionTokens: r.CompletionTokens + o.CompletionTokens,
+		CachedTokens:     r.CachedTokens + o.CachedTokens,
+		TotalTokens:      r.TotalTokens + o.TotalTokens,
+		Cost:             r.Cost.Add(o.Cost),
+	}
+}
+
+type contextKey int
+
+const (
+	tenantKey contextKey = iota
+	sessionKey
+)
+
+// WithTenant 为请求标记租户
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey, tenant)
+}
+
+// WithSession 为请求标记会话
+func WithSession(ctx context.Context, session string) context.Context {
+	return context.WithValue(ctx, sessionKey, session)
+}
+
+// KeyFromContext 根据 context 中的标记和模型构造汇总维度
+func KeyFromContext(ctx context.Context, model string) Key {
+	key := Key{Model: model}
+	key.Tenant, _ = ctx.Value(tenantKey).(string)
+	key.Session, _ = ctx.Value(sessionKey).(string)
+	return key
+}
+
+// Tracker 用量统计，并发安全，实现了 client.Middleware
+type Tracker struct {
+	mu      sync.Mutex
+	prices  PriceTable
+	records map[Key]Record
+	pending map[Key]Record // 进行中的请求预占的用量
+	budgets []Budget
+
+	// OnBudget 在请求会超出预算时调用，软预算只触发该回调而不拒绝请求
+	OnBudget func(err *BudgetError)
+}
+
+// NewTracker 创建用量统计，prices 为nil时使用 DefaultPrices
+func NewTracker(prices PriceTable) *Tracker {
+	if prices == nil {
+		prices = DefaultPrices()
+	}
+	return &Tracker{
+		prices:  prices,
+		records: make(map[Key]Record),
+		pending: make(map[Key]Record),
+	}
+}
+
+// WithBudget 添加预算
+func (t *Tracker) WithBudget(budgets ...Budget) *Tracker {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.budgets = append(t.budgets, budgets...)
+	return t
+}
+
+// Record 记录一次调用的用量，返回本次调用的费用
+func (t *Tracker) Record(key Key, u client.Usage) Cost {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.record(key, u)
+}
+
+func (t *Tracker) record(key Key, u client.Usage) Cost {
+	cost := t.prices.Cost(key.Model, u)
+	t.records[key] = t.records[key].Add(Record{
+		Requests:         1,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		CachedTokens:     u.CachedTokens(),
+		TotalTokens:      u.TotalTokens,
+		Cost:             cost,
+	})
+	return cost
+}
+
+// Snapshot 返回所有维度组合的用量副本
+func (t *Tracker) Snapshot() map[Key]Record {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make(map[Key]Record, len(t.records))
+	for k, r := range t.records {
+		out[k] = r
+	}
+	return out
+}
+
+// Total 返回全部用量
+func (t *Tracker) Total() Record {
+	return t.Sum(func(Key) bool { return true })
+}
+
+// Sum 返回满足条件的用量之和
+func (t *Tracker) Sum(match func(Key) bool) Record {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.sum(match)
+}
+
+func (t *Tracker) sum(match func(Key) bool) Record {
+	var total Record
+	for k, r := range t.records {
+		if match(k) {
+			total = total.Add(r)
+		}
+	}
+	return total
+}
+
+// ByModel 按模型汇总用量
+func (t *Tracker) ByModel() map[string]Record {
+	return t.group(func(k Key) string { return k.Model })
+}
+
+// ByTenant 按租户汇总用量
+func (t *Tracker) ByTenant() map[string]Record {
+	return t.group(func(k Key) string { return k.Tenant })
+}
+
+// BySession 按会话汇总用量
+func (t *Tracker) BySession() map[string]Record {
+	return t.group(func(k Key) string { return k.Session })
+}
+
+func (t *Tracker) group(by func(Key) string) map[string]Record {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make(map[string]Record)
+	for k, r := range t.records {
+		out[by(k)] = out[by(k)].Add(r)
+	}
+	return out
+}
+
+// Reset 清空用量
+func (t *Tracker) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.records = make(map[Key]Record)
+}
+
+// WrapChat 实现 client.Middleware，请求前检查并预占预算，成功后按实际用量记录
+// 来自缓存的响应不计入用量，因此应在缓存中间件之前注册
+func (t *Tracker) WrapChat(next client.ChatFunc) client.ChatFunc {
+	return func(ctx context.Context, req client.ChatRequest) (*client.ChatResponse, error) {
+		r, err := t.Check(KeyFromContext(ctx, req.Model), req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := next(ctx, req)
+		if err == nil && resp != nil && !resp.Meta.Cached {
+			r.Record(resp.Usage)
+		} else {
+			r.Release()
+		}
+		return resp, err
+	}
+}
+
+// WrapChatStream 实现 client.Middleware，流式调用按服务端报告的用量记录
+func (t *Tracker) WrapChatStream(next client.ChatStreamFunc) client.ChatStreamFunc {
+	return func(ctx context.Context, req client.ChatRequest, handler client.StreamHandler) (*client.Usage, error) {
+		r, err := t.Check(KeyFromContext(ctx, req.Model), req)
+		if err != nil {
+			return nil, err
+		}
+
+		u, err := next(ctx, req, handler)
+		// 中途失败时服务端已经计费，有用量就记录
+		if u != nil {
+			r.Record(*u)
+		} else {
+			r.Release()
+		}
+		return u, err
+	}
+}
@@ -0,0 +1,269 @@
+package vectorstore
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// HNSWConfig HNSW索引参数
+type HNSWConfig struct {
+	M              int   // 每层最大邻居数（第0层为2M），默认16
+	EfConstruction int   // 构建时的候选集大小，默认200
+	EfSearch       int   // 检索时的候选集大小，默认64，小于k时使用k
+	Seed           int64 // 层级随机数种子，固定种子使构建结果可复现，默认1
+}
+
+// candidate 检索候选
+type candidate struct {
+	id   int
+	dist float64
+}
+
+// hnswIndex 分层可导航小世界图
+// 节点编号与 Store.slots 一致，距离计算委托给 Store
+type hnswIndex struct {
+	config    HNSWConfig
+	dist      func(a, b int) float64
+	levelMult float64
+	rng       *rand.Rand
+
+	links    [][][]int // 节点 -> 层 -> 邻居
+	entry    int
+	maxLevel int
+}
+
+func newHNSW(cfg HNSWConfig, dist func(a, b int) float64) *hnswIndex {
+	if cfg.M <= 0 {
+		cfg.M = 16
+	}
+	if cfg.EfConstruction <= 0 {
+		cfg.EfConstruction = 200
+	}
+	if cfg.EfSearch <= 0 {
+		cfg.EfSearch = 64
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = 1
+	}
+
+	return &hnswIndex{
+		config:    cfg,
+		dist:      dist,
+		levelMult: 1 / math.Log(float64(cfg.M)),
+		rng:       rand.New(rand.NewSource(cfg.Seed)),
+		entry:     -1,
+	}
+}
+
+// maxLinks 返回某层允许的最大邻居数
+func (h *hnswIndex) maxLinks(level int) int {
+	if level == 0 {
+		return 2 * h.config.M
+	}
+	return h.config.M
+}
+
+// insert 插入节点，节点编号必须连续递增
+func (h *hnswIndex) insert(id int) {
+	level := int(-math.Log(1-h.rng.Float64()) * h.levelMult)
+	h.links = append(h.links, make([][]int, level+1))
+
+	if h.entry < 0 {
+		h.entry, h.maxLevel = id, level
+		return
+	}
+
+	distTo := func(n int) float64 { return h.dist(id, n) }
+
+	ep := []candidate{{id: h.entry, dist: distTo(h.entry)}}
+	for l := h.maxLevel; l > level; l-- {
+		ep = h.searchLayer(distTo, ep, 1, l)
+	}
+
+	for l := min(level, h.maxLevel); l >= 0; l-- {
+		found := h.searchLayer(distTo, ep, h.config.EfConstruction, l)
+		neighbors := h.selectNeighbors(found, h.config.M)
+
+		h.links[id][l] = ids(neighbors)
+		for _, n := range neighbors {
+			h.connect(n.id, id, l)
+		}
+		ep = found
+	}
+
+	if level > h.maxLevel {
+		h.entry, h.maxLevel = id, level
+	}
+}
+
+// connect 为节点 n 添加邻居 id，超出上限时重新挑选
+func (h *hnswIndex) connect(n, id, level int) {
+	links := append(h.links[n][level], id)
+	if len(links) <= h.maxLinks(level) {
+		h.links[n][level] = links
+		return
+	}
+
+	cands := make([]candidate, len(links))
+	for i, other := range links {
+		cands[i] = candidate{id: other, dist: h.dist(n, other)}
+	}
+	sort.Slice(cands, func(i, j int) bool { return cands[i].dist < cands[j].dist })
+	h.links[n][level] = ids(h.selectNeighbors(cands, h.maxLinks(level)))
+}
+
+// selectNeighbors 启发式邻居选择：优先保留彼此分散的候选，不足时用被剪掉的候选补齐
+// cands 必须按距离升序排列
+func (h *hnswIndex) selectNeighbors(cands []candidate, m int) []candidate {
+	if len(cands) <= m {
+		return cands
+	}
+
+	selected := make([]candidate, 0, m)
+	var pruned []candidate
+	for _, c := range cands {
+		if len(selected) >= m {
+			break
+		}
+		keep := true
+		for _, s := range selected {
+			if h.dist(c.id, s.id) < c.dist {
+				keep = false
+				break
+			}
+		}
+		if keep {
+			selected = append(selected, c)
+		} else {
+			pruned = append(pruned, c)
+		}
+	}
+	for _, c := range pruned {
+		if len(selected) >= m {
+			break
+		}
+		selected = append(selected, c)
+	}
+	return selected
+}
+
+// searchLayer 在指定层做贪心束搜索，返回按距离升序排列的至多 ef 个候选
+func (h *hnswIndex) searchLayer(distTo func(int) float64, entry []candidate, ef, level int) []candidate {
+	visited := make(map[int]struct{}, ef*4)
+	pending := &minHeap{}
+	results := &maxHeap{}
+
+	for _, e := range entry {
+		visited[e.id] = struct{}{}
+		heap.Push(pending, e)
+		heap.Push(results, e)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for pending.Len() > 0 {
+		c := heap.Pop(pending).(candidate)
+		if results.Len() >= ef && c.dist > (*results)[0].dist {
+			break
+		}
+
+		if level >= len(h.links[c.id]) {
+			continue
+		}
+		for _, n := range h.links[c.id][level] {
+			if _, ok := visited[n]; ok {
+				continue
+			}
+			visited[n] = struct{}{}
+
+			d := distTo(n)
+			if results.Len() < ef || d < (*results)[0].dist {
+				heap.Push(pending, candidate{id: n, dist: d})
+				heap.Push(results, candidate{id: n, dist: d})
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	out := make([]candidate, results.Len())
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = heap.Pop(results).(candidate)
+	}
+	return out
+}
+
+// search 检索 k 个满足 accept 的最近邻
+// 满足条件的结果不足时逐步扩大候选集，直到覆盖全部节点
+func (h *hnswIndex) search(q []float64, k int, accept func(id int) bool, distTo func([]float64, int) float64) []candidate {
+	if h.entry < 0 {
+		return nil
+	}
+
+	d := func(n int) float64 { return distTo(q, n) }
+
+	ep := []candidate{{id: h.entry, dist: d(h.entry)}}
+	for l := h.maxLevel; l > 0; l-- {
+		ep = h.searchLayer(d, ep, 1, l)
+	}
+
+	ef := max(h.config.EfSearch, k)
+	for {
+		found := h.searchLayer(d, ep, ef, 0)
+
+		hits := make([]candidate, 0, k)
+		for _, c := range found {
+			if accept(c.id) {
+				hits = append(hits, c)
+				if len(hits) == k {
+					return hits
+				}
+			}
+		}
+
+		if ef >= len(h.links) {
+			return hits
+		}
+		ef *= 2
+	}
+}
+
+func ids(cands []candidate) []int {
+	out := make([]int, len(cands))
+	for i, c := range cands {
+		out[i] = c.id
+	}
+	return out
+}
+
+// minHeap 按距离升序的堆
+type minHeap []candidate
+
+func (h minHeap) Len() int           { return len(h) }
+func (h minHeap) Less(i, j int) bool { return h[i].dist < h[j].dist }
+func (h minHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
+
+// maxHeap 按距离降序的堆，用于维护当前最优的 ef 个结果
+type maxHeap []candidate
+
+func (h maxHeap) Len() int           { return len(h) }
+func (h maxHeap) Less(i, j int) bool { return h[i].dist > h[j].dist }
+func (h maxHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x any)        { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() any {
+	old := *h
+	x := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return x
+}
@@ -0,0 +1,435 @@
+// Package vectorstore 提供进程内的向量存储与近邻检索
+//
+// 支持余弦、点积和欧氏距离三种度量，暴力检索(Flat)和HNSW两种索引，
+// 元数据过滤以及保存到文件。
+package vectorstore
+
+import (
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// Metric 相似度度量
+type Metric string
+
+// 支持的度量
+const (
+	Cosine Metric = "cosine"
+	Dot    Metric = "dot"
+	L2     Metric = "l2"
+)
+
+// IndexType 索引类型
+type IndexType string
+
+// 支持的索引类型
+const (
+	IndexFlat IndexType = "flat"
+	IndexHNSW IndexType = "hnsw"
+)
+
+// Record 存储的一条记录
+type Record struct {
+	ID       string
+	Vector   []float64
+	Content  string
+	Metadata map[string]string
+}
+
+// Result 检索结果
+// Score 越大越相似：余弦为余弦相似度，点积为内积，欧氏距离为 1/(1+距离)
+type Result struct {
+	Record
+	Score float64
+}
+
+// Filter 元数据过滤条件，返回true表示保留
+type Filter func(metadata map[string]string) bool
+
+// Eq 元数据字段等于指定值
+func Eq(key, value string) Filter {
+	return func(md map[string]string) bool {
+		return md[key] == value
+	}
+}
+
+// In 元数据字段属于指定值之一
+func In(key string, values ...string) Filter {
+	return func(md map[string]string) bool {
+		v, ok := md[key]
+		if !ok {
+			return false
+		}
+		for _, want := range values {
+			if v == want {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// And 同时满足所有条件
+func And(filters ...Filter) Filter {
+	return func(md map[string]string) bool {
+		for _, f := range filters {
+			if f != nil && !f(md) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Or 满足任一条件
+func Or(filters ...Filter) Filter {
+	return func(md map[string]string) bool {
+		for _, f := range filters {
+			if f != nil && f(md) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Options 存储选项
+type Options struct {
+	Metric Metric    // 默认 Cosine
+	Index  IndexType // 默认 IndexFlat
+	HNSW   HNSWConfig
+}
+
+// Store 向量存储，并发安全
+type Store struct {
+	opts Options
+
+	mu      sync.RWMutex
+	dim     int
+	slots   []*slot        // 按内部编号保存的记录
+	ids     map[string]int // 记录ID到内部编号
+	hnsw    *hnswIndex     // 仅 IndexHNSW 时非nil
+	deleted int
+}
+
+type slot struct {
+	record  Record
+	vector  []float64 // 参与计算的向量，余弦度量下已归一化
+	deleted bool      // 已删除的节点保留在HNSW图中用于导航
+}
+
+// ErrDimensionMismatch 向量维度与已存储的记录不一致
+var ErrDimensionMismatch = errors.New("vector dimension mismatch")
+
+// New 创建向量存储
+func New(opts Options) *Store {
+	if opts.Metric == "" {
+		opts.Metric = Cosine
+	}
+	if opts.Index == "" {
+		opts.Index = IndexFlat
+	}
+
+	s := &Store{
+		opts: opts,
+		ids:  make(map[string]int),
+	}
+	if opts.Index == IndexHNSW {
+		s.hnsw = newHNSW(opts.HNSW, s.distance)
+	}
+	return s
+}
+
+// Len 返回记录数
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.ids)
+}
+
+// Upsert 写入记录，ID已存在时替换
+func (s *Store) Upsert(records ...Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, r := range records {
+		if r.ID == "" {
+			return errors.New("record id is empty")
+		}
+		if len(r.Vector) == 0 {
+			return fmt.Errorf("record %s: vector is empty", r.ID)
+		}
+		if s.dim == 0 {
+			s.dim = len(r.Vector)
+		} else if len(r.Vector) != s.dim {
+			return fmt.Errorf("record %s: %w: got %d, want %d", r.ID, ErrDimensionMismatch, len(r.Vector), s.dim)
+		}
+
+		if old, ok := s.ids[r.ID]; ok {
+			s.remove(old)
+		}
+		s.insert(r)
+	}
+	return nil
+}
+
+// Delete 删除记录，不存在的ID会被忽略
+func (s *Store) Delete(ids ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, id := range ids {
+		if slot, ok := s.ids[id]; ok {
+			s.remove(slot)
+			delete(s.ids, id)
+		}
+	}
+}
+
+// remove 将内部编号标记为删除；HNSW图中保留该节点用于导航
+func (s *Store) remove(id int) {
+	s.slots[id].deleted = true
+	s.deleted++
+}
+
+// Get 按ID获取记录
+func (s *Store) Get(id string) (Record, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	slot, ok := s.ids[id]
+	if !ok {
+		return Record{}, false
+	}
+	return s.slots[slot].record, true
+}
+
+// Search 检索与 query 最相似的 k 条记录，filter 为nil表示不过滤
+func (s *Store) Search(query []float64, k int, filter Filter) ([]Result, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if k <= 0 || len(s.ids) == 0 {
+		return nil, nil
+	}
+	if len(query) != s.dim {
+		return nil, fmt.Errorf("query: %w: got %d, want %d", ErrDimensionMismatch, len(query), s.dim)
+	}
+
+	q := make([]float64, len(query))
+	copy(q, query)
+	if s.opts.Metric == Cosine {
+		normalize(q)
+	}
+
+	accept := func(id int) bool {
+		slot := s.slots[id]
+		return !slot.deleted && (filter == nil || filter(slot.record.Metadata))
+	}
+
+	var hits []candidate
+	if s.hnsw != nil {
+		hits = s.hnsw.search(q, k, accept, s.distanceTo)
+	}
+	// 过滤条件过严导致HNSW结果不足时退化为暴力检索
+	if s.hnsw == nil || len(hits) < k {
+		hits = s.bruteForce(q, k, accept)
+	}
+
+	results := make([]Result, len(hits))
+	for i, h := range hits {
+		results[i] = Result{Record: s.slots[h.id].record, Score: s.score(h.dist)}
+	}
+	return results, nil
+}
+
+// bruteForce 暴力检索
+func (s *Store) bruteForce(q []float64, k int, accept func(id int) bool) []candidate {
+	var hits []candidate
+	for id := range s.slots {
+		if !accept(id) {
+			continue
+		}
+		hits = append(hits, candidate{id: id, dist: s.distanceTo(q, id)})
+	}
+
+	sort.Slice(hits, func(i, j int) bool { return hits[i].dist < hits[j].dist })
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits
+}
+
+// distance 两个内部节点之间的距离
+func (s *Store) distance(a, b int) float64 {
+	return s.distanceTo(s.slots[a].vector, b)
+}
+
+// distanceTo 向量与内部节点之间的距离，越小越相似
+func (s *Store) distanceTo(q []float64, id int) float64 {
+	v := s.slots[id].vector
+	switch s.opts.Metric {
+	case L2:
+		var sum float64
+		for i := range q {
+			d := q[i] - v[i]
+			sum += d * d
+		}
+		return math.Sqrt(sum)
+	case Cosine:
+		return 1 - dot(q, v)
+	default:
+		return -dot(q, v)
+	}
+}
+
+// score 将距离转换为越大越相似的分数
+func (s *Store) score(dist float64) float64 {
+	switch s.opts.Metric {
+	case L2:
+		return 1 / (1 + dist)
+	case Cosine:
+		return 1 - dist
+	default:
+		return -dist
+	}
+}
+
+// Compact 重建存储以清除已删除的记录，HNSW索引会重新构建
+func (s *Store) Compact() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.deleted == 0 {
+		return
+	}
+	s.rebuild(s.liveRecords())
+}
+
+// rebuild 用给定记录重建内部结构，调用方需持有写锁
+func (s *Store) rebuild(records []Record) {
+	s.slots = nil
+	s.ids = make(map[string]int)
+	s.deleted = 0
+	if s.hnsw != nil {
+		s.hnsw = newHNSW(s.opts.HNSW, s.distance)
+	}
+
+	for _, r := range records {
+		s.insert(r)
+	}
+}
+
+// insert 追加一条记录，调用方需持有写锁
+func (s *Store) insert(r Record) {
+	vector := make([]float64, len(r.Vector))
+	copy(vector, r.Vector)
+	if s.opts.Metric == Cosine {
+		normalize(vector)
+	}
+
+	id := len(s.slots)
+	s.slots = append(s.slots, &slot{record: r, vector: vector})
+	s.ids[r.ID] = id
+	if s.hnsw != nil {
+		s.hnsw.insert(id)
+	}
+}
+
+// liveRecords 按写入顺序返回未删除的记录
+func (s *Store) liveRecords() []Record {
+	records := make([]Record, 0, len(s.ids))
+	for _, slot := range s.slots {
+		if !slot.deleted {
+			records = append(records, slot.record)
+		}
+	}
+	return records
+}
+
+// fileFormat 持久化文件格式
+type fileFormat struct {
+	Version int
+	Options Options
+	Records []Record
+}
+
+const fileVersion = 1
+
+// Save 保存到文件（gob编码）
+// HNSW图不会保存，加载时按记录顺序重新构建
+func (s *Store) Save(path string) error {
+	s.mu.RLock()
+	data := fileFormat{
+		Version: fileVersion,
+		Options: s.opts,
+		Records: s.liveRecords(),
+	}
+	s.mu.RUnlock()
+
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := gob.NewEncoder(f).Encode(&data); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Load 从文件加载存储
+func Load(path string) (*Store, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var data fileFormat
+	if err := gob.NewDecoder(f).Decode(&data); err != nil {
+		return nil, fmt.Errorf("decode vector store: %w", err)
+	}
+	if data.Version != fileVersion {
+		return nil, fmt.Errorf("unsupported vector store version: %d", data.Version)
+	}
+
+	s := New(data.Options)
+	if err := s.Upsert(data.Records...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func dot(a, b []float64) float64 {
+	var sum float64
+	for i := range a {
+		sum += a[i] * b[i]
+	}
+	return sum
+}
+
+func normalize(v []float64) {
+	var sum float64
+	for _, x := range v {
+		sum += x * x
+	}
+	if sum == 0 {
+		return
+	}
+	norm := math.Sqrt(sum)
+	for i := range v {
+		v[i] /= norm
+	}
+}